@@ -0,0 +1,438 @@
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sapk/protoc-gen-openapiv3/options"
+)
+
+// Sections of the components object holding the reusable objects declared by the components file option
+const (
+	responsesSection     = "responses"
+	parametersSection    = "parameters"
+	headersSection       = "headers"
+	examplesSection      = "examples"
+	requestBodiesSection = "requestBodies"
+	linksSection         = "links"
+)
+
+// referenceSections maps the annotation messages that can reference a component to their section
+var referenceSections = map[protoreflect.FullName]string{
+	(&options.Response{}).ProtoReflect().Descriptor().FullName():    responsesSection,
+	(&options.Parameter{}).ProtoReflect().Descriptor().FullName():   parametersSection,
+	(&options.Header{}).ProtoReflect().Descriptor().FullName():      headersSection,
+	(&options.Example{}).ProtoReflect().Descriptor().FullName():     examplesSection,
+	(&options.RequestBody{}).ProtoReflect().Descriptor().FullName(): requestBodiesSection,
+	(&options.Link{}).ProtoReflect().Descriptor().FullName():        linksSection,
+}
+
+// componentRef returns the JSON pointer of a component reference,
+// which is either the name of a component of the section or already a pointer
+func componentRef(section, ref string) string {
+	if strings.Contains(ref, "#") {
+		return ref
+	}
+	return "#/components/" + section + "/" + ref
+}
+
+// componentName returns the name of the component of the section a reference points to,
+// given either its name or its JSON pointer
+func componentName(section, ref string) string {
+	if name, ok := strings.CutPrefix(ref, "#/components/"+section+"/"); ok {
+		return name
+	}
+	return ref
+}
+
+// refExtensions returns the $ref entry rendering an object as a component reference.
+// The libopenapi high-level objects have no reference field, the entry is rendered like an extension.
+func refExtensions(section, ref string) *orderedmap.Map[string, *yaml.Node] {
+	extensions := orderedmap.New[string, *yaml.Node]()
+	extensions.Set("$ref", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: componentRef(section, ref)})
+	return extensions
+}
+
+// componentNames returns the names of the components of a section declared by the components file option
+func componentNames(components *options.Components, section string) []string {
+	var names []string
+	switch section {
+	case responsesSection:
+		names = slices.Collect(maps.Keys(components.GetResponses()))
+	case parametersSection:
+		names = slices.Collect(maps.Keys(components.GetParameters()))
+	case headersSection:
+		names = slices.Collect(maps.Keys(components.GetHeaders()))
+	case examplesSection:
+		names = slices.Collect(maps.Keys(components.GetExamples()))
+	case requestBodiesSection:
+		names = slices.Collect(maps.Keys(components.GetRequestBodies()))
+	case linksSection:
+		names = slices.Collect(maps.Keys(components.GetLinks()))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validateComponentRefs checks that the component names referenced by the annotations of a file
+// are declared by the given components. JSON pointers are left to the reader of the document.
+func validateComponentRefs(parsedFile *ParsedFile, components *options.Components) error {
+	msgs := []proto.Message{parsedFile.Components}
+	for _, resp := range parsedFile.DefaultResponses {
+		msgs = append(msgs, resp)
+	}
+	for _, service := range parsedFile.Services {
+		for _, resp := range service.DefaultResponses {
+			msgs = append(msgs, resp)
+		}
+		for _, method := range service.Methods {
+			msgs = append(msgs, method.RequestBody)
+			for _, param := range method.Parameters {
+				msgs = append(msgs, param)
+			}
+			for _, resp := range method.Responses {
+				msgs = append(msgs, resp)
+			}
+		}
+	}
+
+	for _, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+		if err := validateMessageRefs(components, msg.ProtoReflect()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMergedComponentRefs checks the component references of merged files against the components
+// declared by all of them, a file being allowed to reference the components of another
+func validateMergedComponentRefs(parsedFiles []*ParsedFile) error {
+	components := &options.Components{}
+	for _, parsedFile := range parsedFiles {
+		if parsedFile.Components != nil {
+			proto.Merge(components, parsedFile.Components)
+		}
+	}
+
+	for _, parsedFile := range parsedFiles {
+		if err := validateComponentRefs(parsedFile, components); err != nil {
+			return fmt.Errorf("package %s: %w", parsedFile.Package, err)
+		}
+	}
+	return nil
+}
+
+// validateMessageRefs is the recursive part of validateComponentRefs
+func validateMessageRefs(components *options.Components, msg protoreflect.Message) error {
+	if !msg.IsValid() {
+		return nil
+	}
+
+	if section, ok := referenceSections[msg.Descriptor().FullName()]; ok {
+		ref := msg.Get(msg.Descriptor().Fields().ByName("ref")).String()
+		if ref != "" && !strings.Contains(ref, "#") && !slices.Contains(componentNames(components, section), ref) {
+			return fmt.Errorf("unknown %s component %q referenced by %s", section, ref, msg.Descriptor().Name())
+		}
+	}
+
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				err = validateMessageRefs(components, mv.Message())
+				return err == nil
+			})
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len() && err == nil; i++ {
+				err = validateMessageRefs(components, v.List().Get(i).Message())
+			}
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			err = validateMessageRefs(components, v.Message())
+		}
+		return err == nil
+	})
+	return err
+}
+
+// addComponents adds the reusable objects declared by the components file option to the document
+func addComponents(parsedFile *ParsedFile, doc *high.Document) {
+	components := parsedFile.Components
+	if components == nil {
+		return
+	}
+
+	if len(components.GetResponses()) > 0 {
+		doc.Components.Responses = orderedmap.New[string, *high.Response]()
+		for _, name := range componentNames(components, responsesSection) {
+			doc.Components.Responses.Set(name, convertResponse(parsedFile, components.GetResponses()[name], doc))
+		}
+	}
+	if len(components.GetParameters()) > 0 {
+		doc.Components.Parameters = orderedmap.New[string, *high.Parameter]()
+		for _, name := range componentNames(components, parametersSection) {
+			doc.Components.Parameters.Set(name, convertParameter(parsedFile, components.GetParameters()[name], doc))
+		}
+	}
+	if len(components.GetHeaders()) > 0 {
+		doc.Components.Headers = orderedmap.New[string, *high.Header]()
+		for _, name := range componentNames(components, headersSection) {
+			doc.Components.Headers.Set(name, convertHeader(parsedFile, components.GetHeaders()[name], doc))
+		}
+	}
+	if len(components.GetExamples()) > 0 {
+		doc.Components.Examples = orderedmap.New[string, *base.Example]()
+		for _, name := range componentNames(components, examplesSection) {
+			doc.Components.Examples.Set(name, convertExample(components.GetExamples()[name]))
+		}
+	}
+	if len(components.GetRequestBodies()) > 0 {
+		doc.Components.RequestBodies = orderedmap.New[string, *high.RequestBody]()
+		for _, name := range componentNames(components, requestBodiesSection) {
+			doc.Components.RequestBodies.Set(name, convertRequestBody(parsedFile, components.GetRequestBodies()[name], doc))
+		}
+	}
+	if len(components.GetLinks()) > 0 {
+		doc.Components.Links = orderedmap.New[string, *high.Link]()
+		for _, name := range componentNames(components, linksSection) {
+			doc.Components.Links.Set(name, convertLink(components.GetLinks()[name]))
+		}
+	}
+}
+
+// resolveParameter returns the component a parameter references by name or JSON pointer, or the parameter itself
+func resolveParameter(parsedFile *ParsedFile, param *options.Parameter) *options.Parameter {
+	if component, ok := parsedFile.Components.GetParameters()[componentName(parametersSection, param.GetRef())]; ok {
+		return component
+	}
+	return param
+}
+
+// convertResponse converts a response annotation to an OpenAPI response
+func convertResponse(parsedFile *ParsedFile, resp *options.Response, doc *high.Document) *high.Response {
+	if resp.GetRef() != "" {
+		return &high.Response{Extensions: refExtensions(responsesSection, resp.GetRef())}
+	}
+
+	response := &high.Response{
+		Description: resp.GetDescription(),
+		Extensions:  convertExtensions(resp.GetExtensions()),
+	}
+
+	// Add content if present
+	if len(resp.GetContent()) > 0 {
+		response.Content = orderedmap.New[string, *high.MediaType]()
+		for _, mediaType := range slices.Sorted(maps.Keys(resp.GetContent())) {
+			content := resp.GetContent()[mediaType]
+			if content.GetSchema() == nil && content.GetExample() == "" && len(content.GetExamples()) == 0 {
+				continue
+			}
+			mediaTypeObj := &high.MediaType{
+				Examples: convertExamples(content.GetExamples()),
+			}
+			// If the schema references a message, ensure it is added to components
+			if content.GetSchema() != nil {
+				mediaTypeObj.Schema = convertSchemaToOpenAPI(resolveSchemaRef(parsedFile, content.GetSchema(), doc), doc)
+			}
+			if content.GetExample() != "" {
+				mediaTypeObj.Example = exampleNode(content.GetExample())
+			}
+			response.Content.Set(mediaType, mediaTypeObj)
+		}
+	}
+
+	// Add headers if present
+	if len(resp.GetHeaders()) > 0 {
+		response.Headers = orderedmap.New[string, *high.Header]()
+		for _, name := range slices.Sorted(maps.Keys(resp.GetHeaders())) {
+			response.Headers.Set(name, convertHeader(parsedFile, resp.GetHeaders()[name], doc))
+		}
+	}
+
+	// Add links if present
+	if len(resp.GetLinks()) > 0 {
+		response.Links = orderedmap.New[string, *high.Link]()
+		for _, name := range slices.Sorted(maps.Keys(resp.GetLinks())) {
+			response.Links.Set(name, convertLink(resp.GetLinks()[name]))
+		}
+	}
+
+	return response
+}
+
+// convertParameter converts a parameter annotation to an OpenAPI parameter
+func convertParameter(parsedFile *ParsedFile, param *options.Parameter, doc *high.Document) *high.Parameter {
+	if param.GetRef() != "" {
+		return &high.Parameter{Extensions: refExtensions(parametersSection, param.GetRef())}
+	}
+
+	parameter := &high.Parameter{
+		Name:            param.GetName(),
+		In:              param.GetIn(),
+		Description:     param.GetDescription(),
+		Required:        param.Required,
+		Deprecated:      param.GetDeprecated(),
+		AllowEmptyValue: param.GetAllowEmptyValue(),
+		Style:           param.GetStyle(),
+		Explode:         param.Explode,
+		AllowReserved:   param.GetAllowReserved(),
+		Schema:          convertSchemaToOpenAPI(param.GetSchema(), doc),
+		Examples:        convertExamples(param.GetExamples()),
+		Extensions:      convertExtensions(param.GetExtensions()),
+	}
+
+	// Add example if present
+	if param.GetExample() != "" {
+		parameter.Example = &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Value: param.GetExample(),
+		}
+	}
+
+	// Add content if present
+	if len(param.GetContent()) > 0 {
+		parameter.Content = orderedmap.New[string, *high.MediaType]()
+		for _, mediaType := range slices.Sorted(maps.Keys(param.GetContent())) {
+			parameter.Content.Set(mediaType, &high.MediaType{
+				Schema: convertSchemaToOpenAPI(resolveSchemaRef(parsedFile, param.GetContent()[mediaType].GetSchema(), doc), doc),
+			})
+		}
+	}
+
+	return parameter
+}
+
+// convertHeader converts a header annotation to an OpenAPI header
+func convertHeader(parsedFile *ParsedFile, header *options.Header, doc *high.Document) *high.Header {
+	if header.GetRef() != "" {
+		return &high.Header{Extensions: refExtensions(headersSection, header.GetRef())}
+	}
+
+	converted := &high.Header{
+		Description: header.GetDescription(),
+		Required:    header.GetRequired(),
+		Deprecated:  header.GetDeprecated(),
+		Style:       header.GetStyle(),
+		Explode:     header.GetExplode(),
+		Schema:      convertSchemaToOpenAPI(resolveSchemaRef(parsedFile, header.GetSchema(), doc), doc),
+		Examples:    convertExamples(header.GetExamples()),
+		Extensions:  convertExtensions(header.GetExtensions()),
+	}
+
+	// Add example if present
+	if header.GetExample() != "" {
+		converted.Example = &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Value: header.GetExample(),
+		}
+	}
+
+	return converted
+}
+
+// convertExamples converts a map of example annotations, returning nil when empty
+func convertExamples(examples map[string]*options.Example) *orderedmap.Map[string, *base.Example] {
+	if len(examples) == 0 {
+		return nil
+	}
+
+	converted := orderedmap.New[string, *base.Example]()
+	for _, name := range slices.Sorted(maps.Keys(examples)) {
+		converted.Set(name, convertExample(examples[name]))
+	}
+	return converted
+}
+
+// convertExample converts an example annotation to an OpenAPI example
+func convertExample(example *options.Example) *base.Example {
+	if example.GetRef() != "" {
+		return &base.Example{Extensions: refExtensions(examplesSection, example.GetRef())}
+	}
+
+	converted := &base.Example{
+		Summary:       example.GetSummary(),
+		Description:   example.GetDescription(),
+		ExternalValue: example.GetExternalValue(),
+	}
+	if example.GetValue() != "" {
+		converted.Value = &yaml.Node{Kind: yaml.ScalarNode, Value: example.GetValue()}
+	}
+	return converted
+}
+
+// convertRequestBody converts a request body annotation to an OpenAPI request body
+func convertRequestBody(parsedFile *ParsedFile, body *options.RequestBody, doc *high.Document) *high.RequestBody {
+	if body.GetRef() != "" {
+		return &high.RequestBody{Extensions: refExtensions(requestBodiesSection, body.GetRef())}
+	}
+
+	requestBody := &high.RequestBody{
+		Description: body.GetDescription(),
+		Content:     orderedmap.New[string, *high.MediaType](),
+		Required:    &body.Required,
+		Extensions:  convertExtensions(body.GetExtensions()),
+	}
+
+	// Add content from request body
+	for _, mediaType := range slices.Sorted(maps.Keys(body.GetContent())) {
+		content := body.GetContent()[mediaType]
+		// If the schema references a message, ensure it is added to components
+		mediaTypeObj := &high.MediaType{
+			Schema:   convertSchemaToOpenAPI(resolveSchemaRef(parsedFile, content.GetSchema(), doc), doc),
+			Examples: convertExamples(content.GetExamples()),
+		}
+		if content.GetExample() != "" {
+			mediaTypeObj.Example = exampleNode(content.GetExample())
+		}
+
+		// Add encoding if present
+		if len(content.GetEncoding()) > 0 {
+			mediaTypeObj.Encoding = orderedmap.New[string, *high.Encoding]()
+			for _, name := range slices.Sorted(maps.Keys(content.GetEncoding())) {
+				encoding := content.GetEncoding()[name]
+				mediaTypeObj.Encoding.Set(name, &high.Encoding{
+					ContentType:   encoding.GetContentType(),
+					Style:         encoding.GetStyle(),
+					Explode:       &encoding.Explode,
+					AllowReserved: encoding.GetAllowReserved(),
+				})
+			}
+		}
+
+		requestBody.Content.Set(mediaType, mediaTypeObj)
+	}
+
+	return requestBody
+}
+
+// convertLink converts a link annotation to an OpenAPI link
+func convertLink(link *options.Link) *high.Link {
+	if link.GetRef() != "" {
+		return &high.Link{Extensions: refExtensions(linksSection, link.GetRef())}
+	}
+
+	converted := &high.Link{
+		OperationRef: link.GetOperationRef(),
+		OperationId:  link.GetOperationId(),
+		Parameters:   orderedmap.New[string, string](),
+		RequestBody:  link.GetRequestBody(),
+		Description:  link.GetDescription(),
+		Server:       convertServerToOpenAPI(link.GetServer()),
+	}
+	for _, name := range slices.Sorted(maps.Keys(link.GetParameters())) {
+		converted.Parameters.Set(name, link.GetParameters()[name])
+	}
+	return converted
+}
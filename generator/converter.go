@@ -2,12 +2,16 @@ package generator
 
 import (
 	"fmt"
+	"maps"
+	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	high "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/pb33f/libopenapi/orderedmap"
 	"github.com/sapk/protoc-gen-openapiv3/options"
+	"google.golang.org/protobuf/proto"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,15 +26,75 @@ func splitComment(comment string) (summary string, description string) {
 	return summary, description
 }
 
-// ConvertToOpenAPI converts a ParsedFile to a libopenapi Document
-func ConvertToOpenAPI(parsedFile *ParsedFile) (*high.Document, error) {
+// ConvertToOpenAPI converts a ParsedFile to a libopenapi Document.
+// A nil opts uses the default generator options.
+func ConvertToOpenAPI(parsedFile *ParsedFile, opts *Options) (*high.Document, error) {
 	if parsedFile == nil {
 		return nil, fmt.Errorf("parsedFile is nil")
 	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	// Resolve component names from the file alone when the parser did not
+	if parsedFile.SchemaNames == nil {
+		types := parsedFileTypes(parsedFile)
+		if usesDefaultErrorResponse(parsedFile, opts) {
+			addErrorTypes(types, opts)
+		}
+		schemaNames, err := resolveSchemaNames(types, opts.namingStrategy())
+		if err != nil {
+			return nil, err
+		}
+		withNames := *parsedFile
+		withNames.SchemaNames = schemaNames
+		parsedFile = &withNames
+	}
+
+	// Validate specification extensions and operation ids before converting anything
+	if err := validateParsedFileExtensions(parsedFile); err != nil {
+		return nil, err
+	}
+	if err := validateOperationIDOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := validateExplicitOperationIDs(parsedFile); err != nil {
+		return nil, err
+	}
+	// Merged files may reference the components of each other, they are checked once all are converted
+	if !opts.AllowMerge {
+		if err := validateComponentRefs(parsedFile, parsedFile.Components); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateDefaultResponses(parsedFile); err != nil {
+		return nil, err
+	}
+	schemes, err := securitySchemeNames(parsedFile)
+	if err != nil {
+		return nil, err
+	}
+	// Merged files may require the security schemes of each other, they are checked once all are converted
+	if !opts.AllowMerge {
+		if err := validateSecurityRequirements(parsedFile, schemes); err != nil {
+			return nil, err
+		}
+	}
+
+	// Select the OpenAPI version of the document
+	var version string
+	switch opts.OpenAPIVersion {
+	case OpenAPIVersion30:
+		version = "3.0.3"
+	case OpenAPIVersion31, "":
+		version = "3.1.0"
+	default:
+		return nil, fmt.Errorf("unsupported OpenAPI version %q: must be %s or %s", opts.OpenAPIVersion, OpenAPIVersion30, OpenAPIVersion31)
+	}
 
 	// Create the root document
 	doc := &high.Document{
-		Version: "3.1.0",
+		Version: version,
 		Info: &base.Info{
 			Title:   parsedFile.Package,
 			Version: "1.0.0",
@@ -45,21 +109,32 @@ func ConvertToOpenAPI(parsedFile *ParsedFile) (*high.Document, error) {
 		Tags: make([]*base.Tag, 0),
 	}
 
+	// Set the JSON Schema dialect, the file option taking precedence over the plugin parameter
+	doc.JsonSchemaDialect = opts.JSONSchemaDialect
+	if parsedFile.JSONSchemaDialect != "" {
+		doc.JsonSchemaDialect = parsedFile.JSONSchemaDialect
+	}
+	if doc.JsonSchemaDialect != "" && isOpenAPI30(doc) {
+		return nil, fmt.Errorf("jsonSchemaDialect %q requires OpenAPI %s", doc.JsonSchemaDialect, OpenAPIVersion31)
+	}
+	if doc.JsonSchemaDialect != "" {
+		if dialectURL, err := url.Parse(doc.JsonSchemaDialect); err != nil || !dialectURL.IsAbs() {
+			return nil, fmt.Errorf("invalid jsonSchemaDialect %q: must be an absolute URI", doc.JsonSchemaDialect)
+		}
+	}
+
 	// Convert Tags if present
-	if len(parsedFile.Tags) > 0 {
-		doc.Tags = make([]*base.Tag, len(parsedFile.Tags))
-		for i, tag := range parsedFile.Tags {
-			doc.Tags[i] = &base.Tag{
-				Name:        tag.GetName(),
-				Description: tag.GetDescription(),
-			}
-			if tag.GetExternalDocs() != nil {
-				doc.Tags[i].ExternalDocs = &base.ExternalDoc{
-					Description: tag.GetExternalDocs().GetDescription(),
-					URL:         tag.GetExternalDocs().GetUrl(),
-				}
-			}
+	for _, tag := range parsedFile.Tags {
+		doc.Tags = append(doc.Tags, convertTagToOpenAPI(tag, qualifyTagName(parsedFile, tag.GetName(), opts)))
+	}
+
+	// Document the service tags not already documented by the file
+	for _, service := range parsedFile.Services {
+		name := serviceTag(parsedFile, service, opts)
+		if service.Tag == nil || slices.ContainsFunc(doc.Tags, func(tag *base.Tag) bool { return tag.Name == name }) {
+			continue
 		}
+		doc.Tags = append(doc.Tags, convertTagToOpenAPI(service.Tag, name))
 	}
 
 	// Convert Info object if present
@@ -69,22 +144,25 @@ func ConvertToOpenAPI(parsedFile *ParsedFile) (*high.Document, error) {
 			Description:    parsedFile.Info.GetDescription(),
 			TermsOfService: parsedFile.Info.GetTermsOfService(),
 			Version:        parsedFile.Info.GetVersion(),
+			Extensions:     convertExtensions(parsedFile.Info.GetExtensions()),
 		}
 
 		// Handle Contact
 		if parsedFile.Info.Contact != nil {
 			doc.Info.Contact = &base.Contact{
-				Name:  parsedFile.Info.Contact.GetName(),
-				URL:   parsedFile.Info.Contact.GetUrl(),
-				Email: parsedFile.Info.Contact.GetEmail(),
+				Name:       parsedFile.Info.Contact.GetName(),
+				URL:        parsedFile.Info.Contact.GetUrl(),
+				Email:      parsedFile.Info.Contact.GetEmail(),
+				Extensions: convertExtensions(parsedFile.Info.Contact.GetExtensions()),
 			}
 		}
 
 		// Handle License
 		if parsedFile.Info.License != nil {
 			doc.Info.License = &base.License{
-				Name: parsedFile.Info.License.GetName(),
-				URL:  parsedFile.Info.License.GetUrl(),
+				Name:       parsedFile.Info.License.GetName(),
+				URL:        parsedFile.Info.License.GetUrl(),
+				Extensions: convertExtensions(parsedFile.Info.License.GetExtensions()),
 			}
 		}
 	}
@@ -94,463 +172,546 @@ func ConvertToOpenAPI(parsedFile *ParsedFile) (*high.Document, error) {
 		doc.ExternalDocs = &base.ExternalDoc{
 			Description: parsedFile.ExternalDocs.GetDescription(),
 			URL:         parsedFile.ExternalDocs.GetUrl(),
+			Extensions:  convertExtensions(parsedFile.ExternalDocs.GetExtensions()),
 		}
 	}
 
+	// Convert document Extensions if present
+	if len(parsedFile.Extensions) > 0 {
+		doc.Extensions = convertExtensions(parsedFile.Extensions)
+	}
+
 	// Convert Security Schemes if present
-	if len(parsedFile.SecuritySchemes) > 0 {
-		for _, scheme := range parsedFile.SecuritySchemes {
-			securityScheme := &high.SecurityScheme{
-				Type:             scheme.GetType(),
-				Description:      scheme.GetDescription(),
-				Name:             scheme.GetName(),
-				In:               scheme.GetIn(),
-				Scheme:           scheme.GetScheme(),
-				BearerFormat:     scheme.GetBearerFormat(),
-				OpenIdConnectUrl: scheme.GetOpenIdConnectUrl(),
+	if err := addSecuritySchemes(parsedFile, doc); err != nil {
+		return nil, err
+	}
+
+	// Set global security requirements if present
+	if len(parsedFile.Security) > 0 {
+		doc.Security = convertSecurityRequirements(parsedFile.Security)
+	}
+
+	// Convert Servers if present
+	if len(parsedFile.Servers) > 0 {
+		doc.Servers = make([]*high.Server, len(parsedFile.Servers))
+		for i, server := range parsedFile.Servers {
+			doc.Servers[i] = convertServerToOpenAPI(server)
+		}
+	}
+
+	// Convert reusable components referenced by the operations
+	addComponents(parsedFile, doc)
+
+	// Convert services to paths, remembering the operations whose id comes from an annotation
+	var annotated []*high.Operation
+	for _, service := range parsedFile.Services {
+		for _, method := range service.Methods {
+			// RPCs without an HTTP rule documented as the callback of another RPC have no path
+			if method.callbackOnly() {
+				continue
 			}
 
-			// Handle OAuth2 flows if present
-			if scheme.GetFlows() != nil {
-				flows := &high.OAuthFlows{}
-
-				// Handle Authorization Code flow
-				if authCode := scheme.GetFlows().GetAuthorizationCode(); authCode != nil {
-					flows.AuthorizationCode = &high.OAuthFlow{
-						AuthorizationUrl: authCode.GetAuthorizationUrl(),
-						TokenUrl:         authCode.GetTokenUrl(),
-						RefreshUrl:       authCode.GetRefreshUrl(),
-						Scopes:           orderedmap.New[string, string](),
-					}
-					// Add scopes
-					for _, scope := range authCode.GetScopes() {
-						flows.AuthorizationCode.Scopes.Set(scope.GetName(), scope.GetDescription())
-					}
+			// Webhooks are documented under the top-level webhooks object instead of paths
+			if webhook := webhookFor(service, method); webhook != nil {
+				operation, err := addWebhook(parsedFile, service, method, webhook, doc, opts)
+				if err != nil {
+					return nil, err
 				}
+				if method.Operation.GetOperationId() != "" {
+					annotated = append(annotated, operation)
+				}
+				continue
+			}
 
-				// Handle Implicit flow
-				if implicit := scheme.GetFlows().GetImplicit(); implicit != nil {
-					flows.Implicit = &high.OAuthFlow{
-						AuthorizationUrl: implicit.GetAuthorizationUrl(),
-						TokenUrl:         implicit.GetTokenUrl(),
-						RefreshUrl:       implicit.GetRefreshUrl(),
-						Scopes:           orderedmap.New[string, string](),
-					}
-					// Add scopes
-					for _, scope := range implicit.GetScopes() {
-						flows.Implicit.Scopes.Set(scope.GetName(), scope.GetDescription())
-					}
+			// Document the method once per HTTP binding
+			for i, binding := range methodBindings(method) {
+				path := binding.HTTPPath
+				if path == "" {
+					path = convertMethodToPath(binding)
 				}
 
-				// Handle Client Credentials flow
-				if clientCreds := scheme.GetFlows().GetClientCredentials(); clientCreds != nil {
-					flows.ClientCredentials = &high.OAuthFlow{
-						AuthorizationUrl: clientCreds.GetAuthorizationUrl(),
-						TokenUrl:         clientCreds.GetTokenUrl(),
-						RefreshUrl:       clientCreds.GetRefreshUrl(),
-						Scopes:           orderedmap.New[string, string](),
-					}
-					// Add scopes
-					for _, scope := range clientCreds.GetScopes() {
-						flows.ClientCredentials.Scopes.Set(scope.GetName(), scope.GetDescription())
-					}
+				// Get or create path item
+				pathItem, exists := doc.Paths.PathItems.Get(path)
+				if !exists {
+					pathItem = &high.PathItem{}
 				}
 
-				// Handle Password flow
-				if password := scheme.GetFlows().GetPassword(); password != nil {
-					flows.Password = &high.OAuthFlow{
-						AuthorizationUrl: password.GetAuthorizationUrl(),
-						TokenUrl:         password.GetTokenUrl(),
-						RefreshUrl:       password.GetRefreshUrl(),
-						Scopes:           orderedmap.New[string, string](),
-					}
-					// Add scopes
-					for _, scope := range password.GetScopes() {
-						flows.Password.Scopes.Set(scope.GetName(), scope.GetDescription())
-					}
+				operation := convertMethodToOperation(parsedFile, service, binding, path, doc, opts)
+				addDefaultResponses(parsedFile, service, operation, doc)
+				addDefaultErrorResponse(parsedFile, operation, doc, opts)
+				setPathItemOperation(pathItem, binding.HTTPMethod, operation)
+				if i == 0 && method.Operation.GetOperationId() != "" {
+					annotated = append(annotated, operation)
 				}
 
-				securityScheme.Flows = flows
-			}
+				// Add callbacks described by other RPCs
+				if err := addCallbacks(parsedFile, binding, operation, doc, opts); err != nil {
+					return nil, err
+				}
 
-			// Add the security scheme to components
-			doc.Components.SecuritySchemes.Set(scheme.GetType(), securityScheme)
+				// Update path item
+				doc.Paths.PathItems.Set(path, pathItem)
+			}
 		}
 	}
 
-	// Set global security requirements if present
-	if len(parsedFile.Security) > 0 {
-		doc.Security = make([]*base.SecurityRequirement, len(parsedFile.Security))
-		for i, req := range parsedFile.Security {
-			doc.Security[i] = &base.SecurityRequirement{
-				Requirements: orderedmap.New[string, []string](),
+	// Operation ids shared by several operations, like additional bindings, are suffixed
+	ensureUniqueOperationIDs(doc, annotated, opts)
+
+	return doc, nil
+}
+
+// methodBindings returns the method once per HTTP binding, starting with its main binding
+func methodBindings(method ParsedMethod) []ParsedMethod {
+	bindings := []ParsedMethod{method}
+	for _, additional := range method.AdditionalBindings {
+		binding := method
+		binding.HTTPMethod = additional.HTTPMethod
+		binding.HTTPPath = additional.HTTPPath
+		binding.HTTPBody = additional.HTTPBody
+		binding.AdditionalBindings = nil
+		bindings = append(bindings, binding)
+	}
+	return bindings
+}
+
+// convertMethodToOperation converts a parsed method to an OpenAPI operation
+func convertMethodToOperation(parsedFile *ParsedFile, service ParsedService, method ParsedMethod, path string, doc *high.Document, opts *Options) *high.Operation {
+	// Get summary and description from comment
+	summary, description := splitComment(method.Comment)
+
+	// Create the operation
+	operation := &high.Operation{
+		OperationId: operationID(parsedFile, service, method, opts),
+		Summary:     summary,
+		Description: description,
+		Tags:        []string{serviceTag(parsedFile, service, opts)},
+		Responses: &high.Responses{
+			Codes: orderedmap.New[string, *high.Response](),
+		},
+		Parameters: make([]*high.Parameter, 0),
+	}
+
+	// Extract path parameters and add them to the method parameters
+	pathParams := extractPathParameters(path)
+
+	// Set operation annotation
+	if method.Operation != nil {
+		if method.Operation.GetSummary() != "" {
+			operation.Summary = method.Operation.GetSummary()
+		}
+		if method.Operation.GetDescription() != "" {
+			operation.Description = method.Operation.GetDescription()
+		}
+		if len(method.Operation.GetTags()) > 0 {
+			operation.Tags = method.Operation.GetTags()
+		}
+		if method.Operation.GetDeprecated() {
+			operation.Deprecated = &method.Operation.Deprecated
+		}
+		if method.Operation.GetExternalDocs() != nil {
+			operation.ExternalDocs = &base.ExternalDoc{
+				Description: method.Operation.GetExternalDocs().GetDescription(),
+				URL:         method.Operation.GetExternalDocs().GetUrl(),
+				Extensions:  convertExtensions(method.Operation.GetExternalDocs().GetExtensions()),
 			}
-			doc.Security[i].Requirements.Set(req.GetName(), req.GetScopes())
 		}
+		for _, server := range method.Operation.GetServers() {
+			operation.Servers = append(operation.Servers, convertServerToOpenAPI(server))
+		}
+		operation.Extensions = convertExtensions(method.Operation.GetExtensions())
 	}
 
-	// Convert Servers if present
-	if len(parsedFile.Servers) > 0 {
-		doc.Servers = make([]*high.Server, len(parsedFile.Servers))
-		for i, server := range parsedFile.Servers {
-			doc.Servers[i] = convertServerToOpenAPI(server)
+	// Add path parameters that aren't already defined, whether or not the method is annotated
+	for _, param := range pathParams {
+		if !hasParameter(parsedFile, method.Parameters, param, "path") {
+			method.Parameters = append(method.Parameters, &options.Parameter{
+				Name:        param,
+				In:          "path",
+				Required:    pointerTo(true),
+				Schema:      &options.Schema{Type: "string"},
+				Description: fmt.Sprintf("Path parameter %s", param),
+			})
 		}
 	}
 
-	// Convert services to paths
-	for _, service := range parsedFile.Services {
-		for _, method := range service.Methods {
-			path := method.HTTPPath
-			if path == "" {
-				path = convertMethodToPath(method)
+	// Find the input message type
+	var inputMessage *ParsedMessage
+	for _, msg := range parsedFile.Messages {
+		if msg.Name == method.InputType {
+			inputMessage = &msg
+			break
+		}
+	}
+
+	// Add query parameters from input message fields
+	if inputMessage != nil {
+		for _, field := range inputMessage.Fields {
+			// Skip fields that are in the path or body
+			if hasParameter(parsedFile, method.Parameters, field.Name, "path") || field.Name == method.HTTPBody || method.HTTPBody == "*" { // TODO store body field in method	parameters ?
+				continue
 			}
 
-			// Get or create path item
-			pathItem, exists := doc.Paths.PathItems.Get(path)
-			if !exists {
-				pathItem = &high.PathItem{}
+			// Create query parameter
+			param := &options.Parameter{
+				Name:        field.Name,
+				In:          "query",
+				Required:    pointerTo(!strings.HasPrefix(field.Type, "optional")),
+				Description: fmt.Sprintf("Query parameter %s", field.Name),
 			}
 
-			// Get summary and description from comment
-			summary, description := splitComment(method.Comment)
-
-			// Create operation based on HTTP method
-			operation := &high.Operation{
-				OperationId: method.Name,
-				Summary:     summary,
-				Description: description,
-				Tags:        []string{service.Name},
-				Responses: &high.Responses{
-					Codes: orderedmap.New[string, *high.Response](),
-				},
-				Parameters: make([]*high.Parameter, 0),
+			// Handle array type for query parameters
+			if strings.HasPrefix(field.Type, "repeated ") {
+				itemType := strings.TrimPrefix(field.Type, "repeated ")
+				schema := createSchema(itemType, strings.TrimSpace(field.Comment))
+				if schema != nil {
+					param.Schema = &options.Schema{
+						Type:  "array",
+						Items: schema,
+					}
+				} else {
+					param.Schema = &options.Schema{
+						Type:  "array",
+						Items: convertFieldToSchema(&field, parsedFile, doc),
+					}
+				}
+
+				param.Style = "form"
+				param.Explode = pointerTo(true)
+			} else {
+				param.Schema = convertFieldToSchema(&field, parsedFile, doc)
 			}
 
-			// Set operation based on HTTP method
-			switch method.HTTPMethod {
-			case "GET":
-				pathItem.Get = operation
-			case "POST":
-				pathItem.Post = operation
-			case "PUT":
-				pathItem.Put = operation
-			case "PATCH":
-				pathItem.Patch = operation
-			case "DELETE":
-				pathItem.Delete = operation
-			default:
-				// Default to POST if no HTTP method is specified
-				pathItem.Post = operation
+			method.Parameters = append(method.Parameters, param)
+		}
+	}
+
+	// Add parameters from operation
+	if len(method.Parameters) > 0 {
+		operation.Parameters = make([]*high.Parameter, len(method.Parameters))
+		for i, param := range method.Parameters {
+			operation.Parameters[i] = convertParameter(parsedFile, param, doc)
+		}
+	}
+
+	// Set operation security requirements if present, an empty list removing the global ones
+	if len(method.Security) > 0 {
+		operation.Security = convertSecurityRequirements(method.Security)
+	} else if method.Operation.GetNoSecurity() {
+		operation.Security = []*base.SecurityRequirement{}
+	}
+
+	// Add request body if specified
+	if method.RequestBody != nil {
+		operation.RequestBody = convertRequestBody(parsedFile, method.RequestBody, doc)
+	}
+
+	// A referenced request body already describes the whole body
+	if method.HTTPBody != "" && method.RequestBody.GetRef() == "" { //handle generic google.http body
+		if operation.RequestBody == nil {
+			operation.RequestBody = &high.RequestBody{
+				Content: orderedmap.New[string, *high.MediaType](),
+			}
+		}
+		// One media type per consumed content type, all sharing the input message schema
+		var inputSchema *base.SchemaProxy
+		for _, mediaType := range mediaTypesOrDefault(method.Operation.GetConsumes()) {
+			if _, has := operation.RequestBody.Content.Get(mediaType); has {
+				continue
+			}
+			if inputSchema == nil {
+				inputSchema = convertSchemaToOpenAPI(convertMessageToSchema(parsedFile, method.InputType, doc), doc)
 			}
+			operation.RequestBody.Content.Set(mediaType, &high.MediaType{
+				Schema: inputSchema,
+			})
+		}
+	}
 
-			// Extract path parameters and add them to the method parameters
-			pathParams := extractPathParameters(path)
+	// Add a default response if no responses are specified. Server streams are always documented,
+	// unless a 200 response is annotated.
+	annotated200 := slices.ContainsFunc(method.Responses, func(resp *options.Response) bool { return resp.GetCode() == "200" })
+	if len(method.Responses) == 0 || (method.ServerStreaming && !annotated200) {
+		operation.Responses.Codes.Set("200", defaultResponse(parsedFile, method, doc))
+	}
 
-			// Set operation annotation
-			if method.Operation != nil {
-				if method.Operation.GetSummary() != "" {
-					operation.Summary = method.Operation.GetSummary()
-				}
-				if method.Operation.GetDescription() != "" {
-					operation.Description = method.Operation.GetDescription()
-				}
-				if method.Operation.GetDeprecated() {
-					operation.Deprecated = &method.Operation.Deprecated
-				}
+	// Add responses from method's Responses field. An annotated 200 response declaring no content
+	// takes the place of the default response, so it documents the derived content.
+	for _, resp := range method.Responses {
+		response := convertResponse(parsedFile, resp, doc)
+		if resp.GetCode() == "200" && resp.GetRef() == "" && len(resp.GetContent()) == 0 {
+			response.Content = derivedContent(parsedFile, method, doc)
+		}
+		operation.Responses.Codes.Set(resp.GetCode(), response)
+	}
 
-				// Add path parameters that aren't already defined
-				for _, param := range pathParams {
-					if !hasParameter(method.Parameters, param, "path") {
-						method.Parameters = append(method.Parameters, &options.Parameter{
-							Name:        param,
-							In:          "path",
-							Required:    pointerTo(true),
-							Schema:      &options.Schema{Type: "string"},
-							Description: fmt.Sprintf("Path parameter %s", param),
-						})
-					}
-				}
+	markUnsupportedStreaming(method, operation)
 
-				// Find the input message type
-				var inputMessage *ParsedMessage
-				for _, msg := range parsedFile.Messages {
-					if msg.Name == method.InputType {
-						inputMessage = &msg
-						break
-					}
-				}
+	return operation
+}
 
-				// Add query parameters from input message fields
-				if inputMessage != nil {
-					for _, field := range inputMessage.Fields {
-						// Skip fields that are in the path or body
-						if hasParameter(method.Parameters, field.Name, "path") || field.Name == method.HTTPBody { // TODO store body field in method	parameters ?
-							continue
-						}
-
-						// Create query parameter
-						param := &options.Parameter{
-							Name:        field.Name,
-							In:          "query",
-							Required:    pointerTo(!strings.HasPrefix(field.Type, "optional")),
-							Description: fmt.Sprintf("Query parameter %s", field.Name),
-						}
-
-						// Handle array type for query parameters
-						if strings.HasPrefix(field.Type, "repeated ") {
-							itemType := strings.TrimPrefix(field.Type, "repeated ")
-							schema := createSchema(itemType, strings.TrimSpace(field.Comment))
-							if schema != nil {
-								param.Schema = &options.Schema{
-									Type:  "array",
-									Items: schema,
-								}
-							} else {
-								param.Schema = &options.Schema{
-									Type:  "array",
-									Items: convertFieldToSchema(&field, parsedFile, doc),
-								}
-							}
-
-							param.Style = "form"
-							param.Explode = pointerTo(true)
-						} else {
-							param.Schema = convertFieldToSchema(&field, parsedFile, doc)
-						}
-
-						method.Parameters = append(method.Parameters, param)
-					}
-				}
+// defaultResponse returns the 200 response of a method documenting its output message
+func defaultResponse(parsedFile *ParsedFile, method ParsedMethod, doc *high.Document) *high.Response {
+	return &high.Response{
+		Description: fmt.Sprintf("Response for %s operation", method.Name),
+		Content:     derivedContent(parsedFile, method, doc),
+	}
+}
 
-				// Add parameters from operation
-				if len(method.Parameters) > 0 {
-					operation.Parameters = make([]*high.Parameter, len(method.Parameters))
-					for i, param := range method.Parameters {
-						operation.Parameters[i] = &high.Parameter{
-							Name:            param.GetName(),
-							In:              param.GetIn(),
-							Description:     param.GetDescription(),
-							Required:        param.Required,
-							Deprecated:      param.GetDeprecated(),
-							AllowEmptyValue: param.GetAllowEmptyValue(),
-							Style:           param.GetStyle(),
-							Explode:         param.Explode,
-							AllowReserved:   param.GetAllowReserved(),
-							Schema:          convertSchemaToOpenAPI(param.GetSchema(), doc),
-						}
-
-						// Add example if present
-						if param.GetExample() != "" {
-							operation.Parameters[i].Example = &yaml.Node{
-								Kind:  yaml.ScalarNode,
-								Value: param.GetExample(),
-							}
-						}
-
-						// Add examples if present
-						if len(param.GetExamples()) > 0 {
-							operation.Parameters[i].Examples = orderedmap.New[string, *base.Example]()
-							for name, example := range param.GetExamples() {
-								operation.Parameters[i].Examples.Set(name, &base.Example{
-									Summary:       example.GetSummary(),
-									Description:   example.GetDescription(),
-									Value:         &yaml.Node{Value: example.GetValue()},
-									ExternalValue: example.GetExternalValue(),
-								})
-							}
-						}
-
-						// Add content if present
-						if len(param.GetContent()) > 0 {
-							operation.Parameters[i].Content = orderedmap.New[string, *high.MediaType]()
-							for mediaType, content := range param.GetContent() {
-								operation.Parameters[i].Content.Set(mediaType, &high.MediaType{
-									Schema: convertSchemaToOpenAPI(content.GetSchema(), doc),
-								})
-							}
-						}
-					}
-				}
-			}
+// derivedContent returns the content of the output message of a method, one media type per produced
+// content type all sharing the same schema, or nil when the method returns Empty
+func derivedContent(parsedFile *ParsedFile, method ParsedMethod, doc *high.Document) *orderedmap.Map[string, *high.MediaType] {
+	if method.OutputType == "google.protobuf.Empty" {
+		return nil
+	}
 
-			// Set operation security requirements if present
-			if len(method.Security) > 0 {
-				operation.Security = make([]*base.SecurityRequirement, len(method.Security))
-				for i, req := range method.Security {
-					operation.Security[i] = &base.SecurityRequirement{
-						Requirements: orderedmap.New[string, []string](),
-					}
-					operation.Security[i].Requirements.Set(req.GetName(), req.GetScopes())
-				}
-			}
+	outputSchema := convertMessageToSchema(parsedFile, method.OutputType, doc)
+	mediaTypes := mediaTypesOrDefault(method.Operation.GetProduces())
 
-			// Add request body if specified
-			if method.RequestBody != nil {
-				operation.RequestBody = &high.RequestBody{
-					Description: method.RequestBody.GetDescription(),
-					Content:     orderedmap.New[string, *high.MediaType](),
-					Required:    &method.RequestBody.Required,
-				}
+	// Server streams are documented as a sequence of result envelopes
+	if method.ServerStreaming {
+		outputSchema = streamResultSchema(parsedFile, method, doc)
+		mediaTypes = streamingMediaTypesOrDefault(method.Operation.GetProduces())
+	}
 
-				// Add content from request body
-				for mediaType, content := range method.RequestBody.GetContent() {
-					mediaTypeObj := &high.MediaType{
-						Schema: convertSchemaToOpenAPI(content.GetSchema(), doc),
-					}
+	schema := convertSchemaToOpenAPI(outputSchema, doc)
+	content := orderedmap.New[string, *high.MediaType]()
+	for _, mediaType := range mediaTypes {
+		content.Set(mediaType, &high.MediaType{Schema: schema})
+	}
+	return content
+}
 
-					// If the schema has a reference, ensure the referenced message is added to components
-					if content.GetSchema() != nil && content.GetSchema().GetRef() != "" {
-						refName := strings.TrimPrefix(content.GetSchema().GetRef(), "#/components/schemas/")
-						// Find the referenced message
-						for _, msg := range parsedFile.Messages {
-							if msg.Name == refName {
-								// Convert the message to schema and add it to components
-								msgSchema := convertMessageToSchema(parsedFile, msg.Name, doc)
-								doc.Components.Schemas.Set(refName, convertSchemaToOpenAPI(msgSchema, doc))
-								break
-							}
-						}
-					}
+// webhookFor returns the webhook annotation that applies to the method, if any.
+// A method annotation takes precedence over the annotation of its service.
+func webhookFor(service ParsedService, method ParsedMethod) *options.Webhook {
+	if method.Webhook != nil {
+		return method.Webhook
+	}
+	if service.Webhook != nil {
+		// The name of a service annotation does not apply to its individual methods
+		return &options.Webhook{Method: service.Webhook.GetMethod()}
+	}
+	return nil
+}
 
-					// Add examples if present
-					if len(content.GetExamples()) > 0 {
-						mediaTypeObj.Examples = orderedmap.New[string, *base.Example]()
-						for name, example := range content.GetExamples() {
-							mediaTypeObj.Examples.Set(name, &base.Example{
-								Summary:       example.GetSummary(),
-								Description:   example.GetDescription(),
-								Value:         &yaml.Node{Value: example.GetValue()},
-								ExternalValue: example.GetExternalValue(),
-							})
-						}
-					}
+// addWebhook converts a method marked as webhook and adds it to the document webhooks
+func addWebhook(parsedFile *ParsedFile, service ParsedService, method ParsedMethod, webhook *options.Webhook, doc *high.Document, opts *Options) (*high.Operation, error) {
+	name := webhook.GetName()
+	if name == "" {
+		name = method.Name
+	}
+	httpMethod, err := outboundHTTPMethod(webhook.GetMethod())
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: %w", name, err)
+	}
+	operation := convertPayloadOperation(parsedFile, service, method, httpMethod, doc, opts)
 
-					// Add encoding if present
-					if len(content.GetEncoding()) > 0 {
-						mediaTypeObj.Encoding = orderedmap.New[string, *high.Encoding]()
-						for name, encoding := range content.GetEncoding() {
-							mediaTypeObj.Encoding.Set(name, &high.Encoding{
-								ContentType:   encoding.GetContentType(),
-								Style:         encoding.GetStyle(),
-								Explode:       &encoding.Explode,
-								AllowReserved: encoding.GetAllowReserved(),
-							})
-						}
-					}
+	if doc.Webhooks == nil {
+		doc.Webhooks = orderedmap.New[string, *high.PathItem]()
+	}
+	pathItem, exists := doc.Webhooks.Get(name)
+	if !exists {
+		pathItem = &high.PathItem{}
+	}
+	setPathItemOperation(pathItem, httpMethod, operation)
+	doc.Webhooks.Set(name, pathItem)
+	return operation, nil
+}
 
-					operation.RequestBody.Content.Set(mediaType, mediaTypeObj)
-				}
+// addCallbacks converts the callback annotations of a method and adds them to its operation
+func addCallbacks(parsedFile *ParsedFile, method ParsedMethod, operation *high.Operation, doc *high.Document, opts *Options) error {
+	for _, callback := range method.Callbacks {
+		if callback.GetName() == "" || callback.GetExpression() == "" {
+			return fmt.Errorf("callback on method %s requires a name and an expression", method.Name)
+		}
+
+		service, target, found := findMethod(parsedFile, callback.GetRpc())
+		if !found {
+			return fmt.Errorf("callback %q on method %s references unknown RPC %q", callback.GetName(), method.Name, callback.GetRpc())
+		}
+
+		httpMethod, err := outboundHTTPMethod(callback.GetMethod())
+		if err != nil {
+			return fmt.Errorf("callback %q on method %s: %w", callback.GetName(), method.Name, err)
+		}
+		pathItem := &high.PathItem{}
+		setPathItemOperation(pathItem, httpMethod, convertPayloadOperation(parsedFile, service, target, httpMethod, doc, opts))
+
+		if operation.Callbacks == nil {
+			operation.Callbacks = orderedmap.New[string, *high.Callback]()
+		}
+		cb, exists := operation.Callbacks.Get(callback.GetName())
+		if !exists {
+			cb = &high.Callback{
+				Expression: orderedmap.New[string, *high.PathItem](),
 			}
+			operation.Callbacks.Set(callback.GetName(), cb)
+		}
+		cb.Expression.Set(callback.GetExpression(), pathItem)
+	}
 
-			if method.HTTPBody != "" { //handle generic google.http body
-				if operation.RequestBody == nil {
-					operation.RequestBody = &high.RequestBody{
-						Content: orderedmap.New[string, *high.MediaType](),
-					}
-				}
-				if _, has := operation.RequestBody.Content.Get("application/json"); !has {
-					operation.RequestBody.Content.Set("application/json", &high.MediaType{
-						Schema: convertSchemaToOpenAPI(convertMessageToSchema(parsedFile, method.InputType, doc), doc),
-					})
-				}
+	return nil
+}
+
+// methodFullName returns the fully qualified name of a method of the parsed file
+func methodFullName(parsedFile *ParsedFile, service ParsedService, method ParsedMethod) string {
+	return service.fullName(parsedFile.Package) + "." + method.Name
+}
+
+// findMethod looks up a method by its fully-qualified name, among the services of the parsed file
+// and the services of other files holding callback targets
+func findMethod(parsedFile *ParsedFile, fullName string) (ParsedService, ParsedMethod, bool) {
+	fullName = strings.TrimPrefix(fullName, ".")
+	for _, service := range slices.Concat(parsedFile.Services, parsedFile.CallbackServices) {
+		for _, method := range service.Methods {
+			if methodFullName(parsedFile, service, method) == fullName {
+				return service, method, true
 			}
+		}
+	}
+	return ParsedService{}, ParsedMethod{}, false
+}
 
-			// Add responses from method's Responses field
-			if len(method.Responses) > 0 {
-				for _, resp := range method.Responses {
-					response := &high.Response{
-						Description: resp.GetDescription(),
-					}
+// convertPayloadOperation converts a method sent by the API itself (webhook or callback) to an operation.
+// The whole request message is always sent as the payload and the response message is the acknowledgement.
+func convertPayloadOperation(parsedFile *ParsedFile, service ParsedService, method ParsedMethod, httpMethod string, doc *high.Document, opts *Options) *high.Operation {
+	method.HTTPMethod = httpMethod
+	method.HTTPPath = ""
+	method.HTTPBody = "*"
 
-					// Add content if present
-					if len(resp.GetContent()) > 0 {
-						response.Content = orderedmap.New[string, *high.MediaType]()
-						for mediaType, content := range resp.GetContent() {
-							// Convert schema and ensure it's added to components
-							schema := content.GetSchema()
-							if schema != nil {
-								// If the schema has a reference, ensure the referenced message is added to components
-								if schema.GetRef() != "" {
-									refName := strings.TrimPrefix(schema.GetRef(), "#/components/schemas/")
-									// Find the referenced message
-									for _, msg := range parsedFile.Messages {
-										if msg.Name == refName {
-											// Convert the message to schema and add it to components
-											msgSchema := convertMessageToSchema(parsedFile, msg.Name, doc)
-											doc.Components.Schemas.Set(refName, convertSchemaToOpenAPI(msgSchema, doc))
-											break
-										}
-									}
-								}
-								response.Content.Set(mediaType, &high.MediaType{
-									Schema: convertSchemaToOpenAPI(schema, doc),
-								})
-							}
-						}
-					}
+	operation := convertMethodToOperation(parsedFile, service, method, "", doc, opts)
+	if operation.RequestBody.Required == nil {
+		operation.RequestBody.Required = pointerTo(true)
+	}
+	return operation
+}
 
-					// Add headers if present
-					if len(resp.GetHeaders()) > 0 {
-						response.Headers = orderedmap.New[string, *high.Header]()
-						for name, header := range resp.GetHeaders() {
-							response.Headers.Set(name, &high.Header{
-								Description: header.GetDescription(),
-								Required:    header.GetRequired(),
-								Deprecated:  header.GetDeprecated(),
-								Style:       header.GetStyle(),
-								Explode:     header.GetExplode(),
-								Schema:      convertSchemaToOpenAPI(header.GetSchema(), doc),
-							})
-						}
-					}
+// pathItemMethods lists the HTTP methods a path item holds an operation for
+var pathItemMethods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
 
-					// Add links if present
-					if len(resp.GetLinks()) > 0 {
-						response.Links = orderedmap.New[string, *high.Link]()
-						for name, link := range resp.GetLinks() {
-							response.Links.Set(name, &high.Link{
-								OperationRef: link.GetOperationRef(),
-								OperationId:  link.GetOperationId(),
-								Parameters:   orderedmap.New[string, string](),
-								RequestBody:  link.GetRequestBody(),
-								Description:  link.GetDescription(),
-								Server:       convertServerToOpenAPI(link.GetServer()),
-							})
-							// Add parameters to the ordered map
-							linkMap, exists := response.Links.Get(name)
-							if exists && linkMap != nil {
-								for k, v := range link.GetParameters() {
-									linkMap.Parameters.Set(k, v)
-								}
-							}
-						}
-					}
+// outboundHTTPMethod normalizes the HTTP method of a webhook or callback, defaulting to POST.
+// Methods a path item cannot hold are rejected.
+func outboundHTTPMethod(method string) (string, error) {
+	if method == "" {
+		return "POST", nil
+	}
+	httpMethod := strings.ToUpper(method)
+	if !slices.Contains(pathItemMethods, httpMethod) {
+		return "", fmt.Errorf("unsupported HTTP method %q, expected one of %s", method, strings.Join(pathItemMethods, ", "))
+	}
+	return httpMethod, nil
+}
 
-					operation.Responses.Codes.Set(resp.GetCode(), response)
-				}
-			} else {
-				// Default response if no responses are specified
-				response := &high.Response{
-					Description: fmt.Sprintf("Response for %s operation", method.Name),
-				}
+// setPathItemOperation sets the operation on the path item according to the HTTP method
+func setPathItemOperation(pathItem *high.PathItem, httpMethod string, operation *high.Operation) {
+	switch httpMethod {
+	case "GET":
+		pathItem.Get = operation
+	case "PUT":
+		pathItem.Put = operation
+	case "DELETE":
+		pathItem.Delete = operation
+	case "OPTIONS":
+		pathItem.Options = operation
+	case "HEAD":
+		pathItem.Head = operation
+	case "PATCH":
+		pathItem.Patch = operation
+	case "TRACE":
+		pathItem.Trace = operation
+	default:
+		// Methods without an HTTP rule are documented as POST, like grpc-gateway maps them
+		pathItem.Post = operation
+	}
+}
 
-				// Only add content if the response type is not Empty
-				if method.OutputType != "google.protobuf.Empty" {
-					response.Content = orderedmap.New[string, *high.MediaType]()
-					response.Content.Set("application/json", &high.MediaType{
-						Schema: convertSchemaToOpenAPI(convertMessageToSchema(parsedFile, method.OutputType, doc), doc),
-					})
-				}
+// serviceTag returns the tag grouping the operations of a service: the name of its tag annotation,
+// or its name prefixed with the proto package when IncludePackageInTags is set
+func serviceTag(parsedFile *ParsedFile, service ParsedService, opts *Options) string {
+	if service.Tag.GetName() != "" {
+		return service.Tag.GetName()
+	}
+	if opts.IncludePackageInTags && parsedFile.Package != "" {
+		return parsedFile.Package + "." + service.Name
+	}
+	return service.Name
+}
+
+// convertTagToOpenAPI converts a protobuf Tag to an OpenAPI Tag with the given name
+func convertTagToOpenAPI(tag *options.Tag, name string) *base.Tag {
+	openAPITag := &base.Tag{
+		Name:        name,
+		Description: tag.GetDescription(),
+		Extensions:  convertExtensions(tag.GetExtensions()),
+	}
+	if tag.GetExternalDocs() != nil {
+		openAPITag.ExternalDocs = &base.ExternalDoc{
+			Description: tag.GetExternalDocs().GetDescription(),
+			URL:         tag.GetExternalDocs().GetUrl(),
+			Extensions:  convertExtensions(tag.GetExternalDocs().GetExtensions()),
+		}
+	}
+	return openAPITag
+}
 
-				operation.Responses.Codes.Set("200", response)
+// qualifyTagName prefixes a top-level tag definition named after a service of the file
+// so that it keeps describing the operations of that service
+func qualifyTagName(parsedFile *ParsedFile, name string, opts *Options) string {
+	for _, service := range parsedFile.Services {
+		if service.Name == name {
+			return serviceTag(parsedFile, service, opts)
+		}
+	}
+	return name
+}
+
+// validateParsedFileExtensions checks the specification extensions of every option carried by the parsed file
+func validateParsedFileExtensions(parsedFile *ParsedFile) error {
+	if err := validateExtensionKeys(parsedFile.Extensions, "document"); err != nil {
+		return err
+	}
+
+	msgs := []proto.Message{parsedFile.Info, parsedFile.ExternalDocs, parsedFile.Components}
+	for _, server := range parsedFile.Servers {
+		msgs = append(msgs, server)
+	}
+	for _, scheme := range parsedFile.SecuritySchemes {
+		msgs = append(msgs, scheme)
+	}
+	for _, tag := range parsedFile.Tags {
+		msgs = append(msgs, tag)
+	}
+	for _, resp := range parsedFile.DefaultResponses {
+		msgs = append(msgs, resp)
+	}
+	for _, service := range parsedFile.Services {
+		msgs = append(msgs, service.Tag)
+		for _, resp := range service.DefaultResponses {
+			msgs = append(msgs, resp)
+		}
+		for _, method := range service.Methods {
+			msgs = append(msgs, method.Operation, method.RequestBody)
+			for _, param := range method.Parameters {
+				msgs = append(msgs, param)
 			}
+			for _, resp := range method.Responses {
+				msgs = append(msgs, resp)
+			}
+		}
+	}
 
-			// Update path item
-			doc.Paths.PathItems.Set(path, pathItem)
+	for _, msg := range parsedFile.Messages {
+		msgs = append(msgs, msg.Schema)
+		for _, field := range msg.Fields {
+			msgs = append(msgs, field.Schema)
 		}
 	}
+	for _, enum := range parsedFile.Enums {
+		msgs = append(msgs, enum.Schema)
+	}
 
-	return doc, nil
+	return validateExtensions(msgs...)
 }
 
 // convertMethodToPath converts a method name to a path (fallback when no HTTP path is specified)
@@ -585,56 +746,66 @@ func extractPathParameters(path string) []string {
 	return params
 }
 
-// convertMessageToSchema converts a message to a schema
+// mediaTypesOrDefault returns the given media types, or application/json when none are listed
+func mediaTypesOrDefault(mediaTypes []string) []string {
+	if len(mediaTypes) == 0 {
+		return []string{"application/json"}
+	}
+	return mediaTypes
+}
+
+// convertMessageToSchema converts a message or enum type to a reference to its schema component,
+// adding the component to the document the first time the type is seen
 func convertMessageToSchema(parsedFile *ParsedFile, messageName string, doc *high.Document) *options.Schema {
-	// Create a map to track schemas being processed
-	processingSchemas := make(map[string]bool)
-
-	// Inner function to handle the actual conversion
-	var convert func(string) *options.Schema
-	convert = func(name string) *options.Schema {
-		// Check if we're already processing this schema
-		if processingSchemas[name] {
-			// If we are, create a reference to avoid infinite recursion
-			refName := name
-			if strings.Contains(name, ".") {
-				parts := strings.Split(name, ".")
-				refName = parts[len(parts)-1]
-			}
-			return &options.Schema{Ref: fmt.Sprintf("#/components/schemas/%s", refName)}
-		}
+	fullName := strings.TrimPrefix(messageName, ".")
 
-		// Mark this schema as being processed
-		processingSchemas[name] = true
-		defer func() {
-			processingSchemas[name] = false
-		}()
+	// Well-known types have a predefined JSON mapping
+	if fullName == anyType {
+		return anySchema(parsedFile, doc)
+	}
+	if schema := createSchema(fullName, ""); schema != nil {
+		return schema
+	}
 
-		// Try to convert the schema using different handlers
-		if schema := handleMessage(parsedFile, name, doc); schema != nil {
-			return schema
-		}
+	if localName, found := lookupType(parsedFile, fullName); found {
+		fullName = localName
+	}
+	refName := schemaName(parsedFile, fullName)
+	ref := &options.Schema{Ref: fmt.Sprintf("#/components/schemas/%s", refName)}
 
-		if schema := handleEnum(parsedFile, name, doc); schema != nil {
-			return schema
-		}
+	// The component is already converted, or being converted for a recursive type
+	if _, exists := doc.Components.Schemas.Get(refName); exists {
+		return ref
+	}
 
-		// Handle as reference
-		return handleReference(name, doc, convert)
+	// Reserve the component name while converting so recursive types reference it
+	doc.Components.Schemas.Set(refName, nil)
+
+	// Try to convert the schema using different handlers
+	schema := handleMessage(parsedFile, fullName, doc)
+	if schema == nil {
+		schema = handleEnum(parsedFile, fullName)
+	}
+
+	// Types the parser did not resolve are only referenced
+	if schema == nil {
+		doc.Components.Schemas.Delete(refName)
+		return ref
 	}
 
-	return convert(messageName)
+	doc.Components.Schemas.Set(refName, convertSchemaToOpenAPI(schema, doc))
+	return ref
 }
 
 // handleMessage handles conversion of a message type to a schema
-func handleMessage(parsedFile *ParsedFile, name string, doc *high.Document) *options.Schema {
+func handleMessage(parsedFile *ParsedFile, fullName string, doc *high.Document) *options.Schema {
 	if parsedFile == nil {
 		return nil
 	}
 
 	var message *ParsedMessage
 	for i := range parsedFile.Messages {
-		if parsedFile.Messages[i].Name == name {
+		if parsedFile.Messages[i].fullName(parsedFile.Package) == fullName {
 			message = &parsedFile.Messages[i]
 			break
 		}
@@ -652,27 +823,68 @@ func handleMessage(parsedFile *ParsedFile, name string, doc *high.Document) *opt
 		Description: strings.TrimSpace(message.Comment),
 	}
 
-	// Convert fields to properties
+	// Convert fields to properties. The required list of a field annotation names
+	// properties of the message, like protoc-gen-openapiv2 does.
+	var fieldRequired []string
 	for _, field := range message.Fields {
 		property := convertFieldToSchema(&field, parsedFile, doc)
+		if field.Schema != nil {
+			annotation := proto.Clone(field.Schema).(*options.Schema)
+			fieldRequired = append(fieldRequired, annotation.Required...)
+			annotation.Required = nil
+			property = mergeSchema(property, annotation)
+		}
 		schema.Properties[field.Name] = property
 		if !strings.HasPrefix(field.Type, "optional") {
 			schema.Required = append(schema.Required, field.Name)
 		}
 	}
 
+	// The message annotation replaces the documentation and required list derived from the proto
+	schema = mergeSchema(schema, message.Schema)
+	for _, name := range fieldRequired {
+		if !slices.Contains(schema.Required, name) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
 	return schema
 }
 
+// mergeSchema returns the schema generated for a type overridden by the fields set in its annotation.
+// Lists set by the annotation replace the generated ones, and a reference is wrapped in allOf
+// so that the annotation does not document the referenced component.
+func mergeSchema(schema, annotation *options.Schema) *options.Schema {
+	if annotation == nil {
+		return schema
+	}
+
+	merged := &options.Schema{}
+	if schema != nil {
+		merged = proto.Clone(schema).(*options.Schema)
+	}
+	if merged.GetRef() != "" && annotation.GetRef() == "" {
+		merged = &options.Schema{AllOf: []*options.Schema{merged}}
+	}
+	if len(annotation.GetRequired()) > 0 {
+		merged.Required = nil
+	}
+	if len(annotation.GetEnum()) > 0 {
+		merged.Enum = nil
+	}
+	proto.Merge(merged, annotation)
+	return merged
+}
+
 // handleEnum handles conversion of an enum type to a schema
-func handleEnum(parsedFile *ParsedFile, name string, doc *high.Document) *options.Schema {
+func handleEnum(parsedFile *ParsedFile, fullName string) *options.Schema {
 	if parsedFile == nil {
 		return nil
 	}
 
 	var enum *ParsedEnum
 	for i := range parsedFile.Enums {
-		if parsedFile.Enums[i].Name == name {
+		if parsedFile.Enums[i].fullName(parsedFile.Package) == fullName {
 			enum = &parsedFile.Enums[i]
 			break
 		}
@@ -696,29 +908,55 @@ func handleEnum(parsedFile *ParsedFile, name string, doc *high.Document) *option
 		schema.Default = schema.Enum[0]
 	}
 
-	return schema
+	return mergeSchema(schema, enum.Schema)
 }
 
-// handleReference handles conversion of a reference type to a schema
-func handleReference(name string, doc *high.Document, convert func(string) *options.Schema) *options.Schema {
-	// Strip package name from the reference
-	refName := name
-	if strings.Contains(name, ".") {
-		parts := strings.Split(name, ".")
-		refName = parts[len(parts)-1]
+// lookupType finds the fully qualified name of a message or enum of the file,
+// given its fully qualified name, its name or its component name
+func lookupType(parsedFile *ParsedFile, name string) (string, bool) {
+	if parsedFile == nil {
+		return "", false
 	}
 
-	// Check if the schema already exists in components
-	if _, exists := doc.Components.Schemas.Get(refName); !exists {
-		schema := convert(refName)
-		if schema != nil {
-			doc.Components.Schemas.Set(refName, convertSchemaToOpenAPI(schema, doc))
+	matches := func(typeName, fullName string) bool {
+		return name == fullName || name == typeName || name == schemaName(parsedFile, fullName)
+	}
+	for _, msg := range parsedFile.Messages {
+		if fullName := msg.fullName(parsedFile.Package); matches(msg.Name, fullName) {
+			return fullName, true
 		}
 	}
+	for _, enum := range parsedFile.Enums {
+		if fullName := enum.fullName(parsedFile.Package); matches(enum.Name, fullName) {
+			return fullName, true
+		}
+	}
+	return "", false
+}
+
+// schemaName returns the component name of a type, falling back to its name without package
+func schemaName(parsedFile *ParsedFile, fullName string) string {
+	if name, ok := parsedFile.SchemaNames[fullName]; ok {
+		return name
+	}
+	return fullName[strings.LastIndex(fullName, ".")+1:]
+}
 
-	return &options.Schema{
-		Ref: fmt.Sprintf("#/components/schemas/%s", refName),
+// resolveSchemaRef points a component reference written in an annotation to the component of the
+// message or enum it names, adding the component when missing. Other schemas are returned unchanged.
+func resolveSchemaRef(parsedFile *ParsedFile, schema *options.Schema, doc *high.Document) *options.Schema {
+	refName, ok := strings.CutPrefix(schema.GetRef(), "#/components/schemas/")
+	if !ok {
+		return schema
+	}
+	fullName, found := lookupType(parsedFile, refName)
+	if !found {
+		return schema
 	}
+
+	resolved := proto.Clone(schema).(*options.Schema)
+	resolved.Ref = convertMessageToSchema(parsedFile, fullName, doc).GetRef()
+	return resolved
 }
 
 // createSchema creates a schema for a primitive type
@@ -734,7 +972,7 @@ func createSchema(primitiveType string, description string) *options.Schema {
 			Type:        "string",
 			Description: description,
 		}
-	case "int32", "int64", "uint32", "uint64":
+	case "int32", "int64", "uint32", "uint64", "sint32", "sint64", "fixed32", "fixed64", "sfixed32", "sfixed64":
 		return &options.Schema{
 			Type:        "integer",
 			Description: description,
@@ -755,13 +993,68 @@ func createSchema(primitiveType string, description string) *options.Schema {
 			Format:      "date-time",
 			Description: description,
 		}
+	case "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return &options.Schema{
+			Type:        "string",
+			Description: description,
+		}
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return &options.Schema{
+			Type:        "string",
+			Nullable:    pointerTo(true),
+			Description: description,
+		}
+	case "google.protobuf.Int32Value", "google.protobuf.Int64Value", "google.protobuf.UInt32Value", "google.protobuf.UInt64Value":
+		return &options.Schema{
+			Type:        "integer",
+			Nullable:    pointerTo(true),
+			Description: description,
+		}
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return &options.Schema{
+			Type:        "number",
+			Nullable:    pointerTo(true),
+			Description: description,
+		}
+	case "google.protobuf.BoolValue":
+		return &options.Schema{
+			Type:        "boolean",
+			Nullable:    pointerTo(true),
+			Description: description,
+		}
 	case "google.protobuf.Empty":
-		return nil
+		return &options.Schema{
+			Type:        "object",
+			Description: description,
+		}
+	case "google.protobuf.Struct":
+		return &options.Schema{
+			Type:                 "object",
+			AdditionalProperties: &options.Schema_AllowAdditional{AllowAdditional: true},
+			Description:          description,
+		}
+	case "google.protobuf.ListValue":
+		return &options.Schema{
+			Type:        "array",
+			Items:       &options.Schema{},
+			Description: description,
+		}
+	case "google.protobuf.Value":
+		// Any JSON value
+		return &options.Schema{
+			Description: description,
+		}
 	default:
 		return nil
 	}
 }
 
+// isWellKnownType reports whether a type is rendered without a component of its own, or with the
+// predefined google.protobuf.Any component
+func isWellKnownType(fullName string) bool {
+	return fullName == anyType || createSchema(fullName, "") != nil
+}
+
 // convertFieldToSchema converts a field to a schema
 func convertFieldToSchema(field *ParsedField, parsedFile *ParsedFile, doc *high.Document) *options.Schema {
 	// Handle special types
@@ -785,6 +1078,9 @@ func convertFieldToSchema(field *ParsedField, parsedFile *ParsedFile, doc *high.
 
 	if strings.HasPrefix(field.Type, "optional ") {
 		itemType := strings.TrimPrefix(field.Type, "optional ")
+		if schema := createSchema(itemType, strings.TrimSpace(field.Comment)); schema != nil {
+			return schema
+		}
 		return convertMessageToSchema(parsedFile, itemType, doc)
 	}
 
@@ -833,6 +1129,19 @@ func convertSchemaToOpenAPI(schema *options.Schema, doc *high.Document) *base.Sc
 		return nil
 	}
 
+	// nullable and the "null" type only apply to the type of their own schema, so they cannot
+	// make a referenced schema nullable. The null value becomes an alternative to the reference.
+	if schema.GetNullable() && (schema.GetRef() != "" || len(schema.GetAllOf()) > 0) {
+		nonNull := proto.Clone(schema).(*options.Schema)
+		nonNull.Nullable = nil
+		nonNull.Title, nonNull.Description = "", ""
+		return base.CreateSchemaProxy(&base.Schema{
+			Title:       schema.GetTitle(),
+			Description: schema.GetDescription(),
+			AnyOf:       []*base.SchemaProxy{convertSchemaToOpenAPI(nonNull, doc), nullSchema(doc)},
+		})
+	}
+
 	openAPISchema := &base.Schema{
 		Type:        []string{schema.GetType()},
 		Format:      schema.GetFormat(),
@@ -840,10 +1149,39 @@ func convertSchemaToOpenAPI(schema *options.Schema, doc *high.Document) *base.Sc
 		Title:       schema.GetTitle(),
 		Default:     &yaml.Node{Value: schema.GetDefault()},
 		Enum:        nil,
-		Nullable:    schema.Nullable,
 		ReadOnly:    schema.ReadOnly,
 		WriteOnly:   schema.WriteOnly,
 		Deprecated:  schema.Deprecated,
+		Extensions:  convertExtensions(schema.GetExtensions()),
+	}
+
+	if isOpenAPI30(doc) {
+		// OpenAPI 3.0 schemas use nullable and a single example, const is expressed as a single value enum
+		openAPISchema.Nullable = schema.Nullable
+		if schema.GetExample() != "" {
+			openAPISchema.Example = exampleNode(schema.GetExample())
+		}
+		if schema.GetConst() != "" && len(schema.GetEnum()) == 0 {
+			openAPISchema.Enum = []*yaml.Node{{
+				Kind:  yaml.ScalarNode,
+				Value: schema.GetConst(),
+			}}
+		}
+	} else {
+		// OpenAPI 3.1 schemas are JSON Schema: null is a type, examples is an array and const is supported
+		openAPISchema.SchemaTypeRef = schema.GetSchemaDialect()
+		if schema.GetNullable() && schema.GetType() != "" {
+			openAPISchema.Type = append(openAPISchema.Type, "null")
+		}
+		if schema.GetExample() != "" {
+			openAPISchema.Examples = []*yaml.Node{exampleNode(schema.GetExample())}
+		}
+		if schema.GetConst() != "" {
+			openAPISchema.Const = &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Value: schema.GetConst(),
+			}
+		}
 	}
 
 	// Set enum values
@@ -869,22 +1207,41 @@ func convertSchemaToOpenAPI(schema *options.Schema, doc *high.Document) *base.Sc
 	if schema.GetMultipleOf() != 0 {
 		openAPISchema.MultipleOf = &schema.MultipleOf
 	}
-	if schema.GetMaximum() != 0 {
-		openAPISchema.Maximum = &schema.Maximum
-	}
-	if schema.GetExclusiveMaximum() {
-		exclusiveMax := schema.GetExclusiveMaximum()
-		openAPISchema.ExclusiveMaximum = &base.DynamicValue[bool, float64]{
-			A: exclusiveMax,
+	if isOpenAPI30(doc) {
+		// OpenAPI 3.0 exclusive bounds are booleans qualifying maximum/minimum
+		if schema.GetMaximum() != 0 {
+			openAPISchema.Maximum = &schema.Maximum
 		}
-	}
-	if schema.GetMinimum() != 0 {
-		openAPISchema.Minimum = &schema.Minimum
-	}
-	if schema.GetExclusiveMinimum() {
-		exclusiveMin := schema.GetExclusiveMinimum()
-		openAPISchema.ExclusiveMinimum = &base.DynamicValue[bool, float64]{
-			A: exclusiveMin,
+		if schema.GetExclusiveMaximum() {
+			openAPISchema.ExclusiveMaximum = &base.DynamicValue[bool, float64]{
+				A: true,
+			}
+		}
+		if schema.GetMinimum() != 0 {
+			openAPISchema.Minimum = &schema.Minimum
+		}
+		if schema.GetExclusiveMinimum() {
+			openAPISchema.ExclusiveMinimum = &base.DynamicValue[bool, float64]{
+				A: true,
+			}
+		}
+	} else {
+		// OpenAPI 3.1 exclusive bounds are numbers replacing maximum/minimum
+		if schema.GetExclusiveMaximum() {
+			openAPISchema.ExclusiveMaximum = &base.DynamicValue[bool, float64]{
+				N: 1,
+				B: schema.GetMaximum(),
+			}
+		} else if schema.GetMaximum() != 0 {
+			openAPISchema.Maximum = &schema.Maximum
+		}
+		if schema.GetExclusiveMinimum() {
+			openAPISchema.ExclusiveMinimum = &base.DynamicValue[bool, float64]{
+				N: 1,
+				B: schema.GetMinimum(),
+			}
+		} else if schema.GetMinimum() != 0 {
+			openAPISchema.Minimum = &schema.Minimum
 		}
 	}
 
@@ -933,11 +1290,30 @@ func convertSchemaToOpenAPI(schema *options.Schema, doc *high.Document) *base.Sc
 		return base.CreateSchemaProxyRef(schema.GetRef())
 	}
 
-	// Handle properties
+	if schema.GetDiscriminator() != nil {
+		openAPISchema.Discriminator = &base.Discriminator{
+			PropertyName: schema.GetDiscriminator().GetPropertyName(),
+		}
+		if len(schema.GetDiscriminator().GetMapping()) > 0 {
+			openAPISchema.Discriminator.Mapping = orderedmap.New[string, string]()
+			for _, value := range slices.Sorted(maps.Keys(schema.GetDiscriminator().GetMapping())) {
+				openAPISchema.Discriminator.Mapping.Set(value, schema.GetDiscriminator().GetMapping()[value])
+			}
+		}
+	}
+	if schema.GetExternalDocs() != nil {
+		openAPISchema.ExternalDocs = &base.ExternalDoc{
+			Description: schema.GetExternalDocs().GetDescription(),
+			URL:         schema.GetExternalDocs().GetUrl(),
+			Extensions:  convertExtensions(schema.GetExternalDocs().GetExtensions()),
+		}
+	}
+
+	// Handle properties, sorted by name for a stable output
 	if len(schema.GetProperties()) > 0 {
 		openAPISchema.Properties = orderedmap.New[string, *base.SchemaProxy]()
-		for name, prop := range schema.GetProperties() {
-			openAPISchema.Properties.Set(name, convertSchemaToOpenAPI(prop, doc))
+		for _, name := range slices.Sorted(maps.Keys(schema.GetProperties())) {
+			openAPISchema.Properties.Set(name, convertSchemaToOpenAPI(schema.GetProperties()[name], doc))
 		}
 	}
 
@@ -946,6 +1322,7 @@ func convertSchemaToOpenAPI(schema *options.Schema, doc *high.Document) *base.Sc
 		switch {
 		case schema.GetAllowAdditional():
 			openAPISchema.AdditionalProperties = &base.DynamicValue[*base.SchemaProxy, bool]{
+				N: 1,
 				B: true,
 			}
 		case schema.GetAdditionalSchema() != nil:
@@ -988,6 +1365,22 @@ func convertSchemaToOpenAPI(schema *options.Schema, doc *high.Document) *base.Sc
 	return base.CreateSchemaProxy(openAPISchema)
 }
 
+// exampleNode returns the YAML node of a schema example. JSON objects, arrays and strings are decoded,
+// other examples are rendered as written.
+func exampleNode(example string) *yaml.Node {
+	if trimmed := strings.TrimSpace(example); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") ||
+		strings.HasPrefix(trimmed, `"`) {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(trimmed), &node); err == nil && len(node.Content) > 0 {
+			return node.Content[0]
+		}
+	}
+	return &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Value: example,
+	}
+}
+
 // convertServerToOpenAPI converts a protobuf Server to an OpenAPI Server
 func convertServerToOpenAPI(server *options.Server) *high.Server {
 	if server == nil {
@@ -997,6 +1390,7 @@ func convertServerToOpenAPI(server *options.Server) *high.Server {
 	openAPIServer := &high.Server{
 		URL:         server.GetUrl(),
 		Description: server.GetDescription(),
+		Extensions:  convertExtensions(server.GetExtensions()),
 	}
 
 	if len(server.GetVariables()) > 0 {
@@ -1013,9 +1407,52 @@ func convertServerToOpenAPI(server *options.Server) *high.Server {
 	return openAPIServer
 }
 
+// nullSchema returns the schema accepting only null: the "null" type in OpenAPI 3.1, and in OpenAPI 3.0,
+// which has no null type, a nullable schema whose only value is null
+func nullSchema(doc *high.Document) *base.SchemaProxy {
+	if isOpenAPI30(doc) {
+		return base.CreateSchemaProxy(&base.Schema{
+			Type:     []string{"object"},
+			Nullable: pointerTo(true),
+			Enum:     []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}},
+		})
+	}
+	return base.CreateSchemaProxy(&base.Schema{Type: []string{"null"}})
+}
+
+// webhooksExtension moves the webhooks of an OpenAPI 3.0 document, which has no webhooks object,
+// to the x-webhooks extension so that the annotated operations are still documented
+func webhooksExtension(doc *high.Document) error {
+	if !isOpenAPI30(doc) || doc.Webhooks == nil || doc.Webhooks.Len() == 0 {
+		return nil
+	}
+
+	webhooks := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for name, pathItem := range doc.Webhooks.FromOldest() {
+		node, err := pathItem.MarshalYAML()
+		if err != nil {
+			return fmt.Errorf("failed to render webhook %s: %w", name, err)
+		}
+		webhooks.Content = append(webhooks.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, node.(*yaml.Node))
+	}
+
+	if doc.Extensions == nil {
+		doc.Extensions = orderedmap.New[string, *yaml.Node]()
+	}
+	doc.Extensions.Set("x-webhooks", webhooks)
+	doc.Webhooks = nil
+	return nil
+}
+
+// isOpenAPI30 reports whether the document targets OpenAPI 3.0.x
+func isOpenAPI30(doc *high.Document) bool {
+	return strings.HasPrefix(doc.Version, "3.0")
+}
+
 // hasParameter checks if a parameter with the given name and location exists
-func hasParameter(params []*options.Parameter, name, in string) bool {
+func hasParameter(parsedFile *ParsedFile, params []*options.Parameter, name, in string) bool {
 	for _, param := range params {
+		param = resolveParameter(parsedFile, param)
 		if param.GetName() == name && param.GetIn() == in {
 			return true
 		}
@@ -1,9 +1,15 @@
 package generator_test
 
 import (
+	"math"
 	"testing"
 
+	high "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
 
 	"github.com/sapk/protoc-gen-openapiv3/generator"
 	"github.com/sapk/protoc-gen-openapiv3/options"
@@ -153,7 +159,7 @@ func TestConvertToOpenAPI(t *testing.T) {
 	}
 
 	// Convert to OpenAPI
-	doc, err := generator.ConvertToOpenAPI(parsedFile)
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, doc)
 
@@ -219,12 +225,1650 @@ func TestConvertToOpenAPI(t *testing.T) {
 	assert.NotNil(t, content.Schema)
 
 	// Test schemas
-	assert.Equal(t, 4, doc.Components.Schemas.Len())
+	assert.Equal(t, 6, doc.Components.Schemas.Len())
 }
 
 func TestConvertToOpenAPI_NilFile(t *testing.T) {
-	doc, err := generator.ConvertToOpenAPI(nil)
+	doc, err := generator.ConvertToOpenAPI(nil, nil)
 	assert.Error(t, err)
 	assert.Nil(t, doc)
 	assert.Equal(t, "parsedFile is nil", err.Error())
 }
+
+func TestConvertToOpenAPI_ProducesConsumes(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Services: []generator.ParsedService{
+			{
+				Name: "ExportService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "Export",
+						InputType:  "test.package.ExportRequest",
+						OutputType: "test.package.ExportResponse",
+						HTTPMethod: "POST",
+						HTTPPath:   "/v1/export",
+						HTTPBody:   "*",
+						Operation: &options.Operation{
+							Consumes: []string{"application/json", "application/x-protobuf"},
+							Produces: []string{"application/x-ndjson", "application/x-protobuf"},
+						},
+					},
+					{
+						Name:       "Preview",
+						InputType:  "test.package.ExportRequest",
+						OutputType: "test.package.ExportResponse",
+						HTTPMethod: "POST",
+						HTTPPath:   "/v1/preview",
+						HTTPBody:   "*",
+						Operation:  &options.Operation{Produces: []string{"application/x-protobuf"}},
+						Responses:  []*options.Response{{Code: "200", Description: "The preview"}},
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{
+				Name:   "ExportRequest",
+				Fields: []generator.ParsedField{{Name: "query", Type: "string", Number: 1}},
+			},
+			{
+				Name:   "ExportResponse",
+				Fields: []generator.ParsedField{{Name: "line", Type: "string", Number: 1}},
+			},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.NoError(t, err)
+
+	pathItem, ok := doc.Paths.PathItems.Get("/v1/export")
+	assert.True(t, ok)
+
+	// Request body carries one entry per consumed type sharing the same schema
+	requestContent := pathItem.Post.RequestBody.Content
+	assert.Equal(t, 2, requestContent.Len())
+	jsonBody, ok := requestContent.Get("application/json")
+	assert.True(t, ok)
+	protoBody, ok := requestContent.Get("application/x-protobuf")
+	assert.True(t, ok)
+	assert.Same(t, jsonBody.Schema, protoBody.Schema)
+
+	// Default response carries one entry per produced type sharing the same schema
+	response, ok := pathItem.Post.Responses.Codes.Get("200")
+	assert.True(t, ok)
+	assert.Equal(t, 2, response.Content.Len())
+	ndjsonResponse, ok := response.Content.Get("application/x-ndjson")
+	assert.True(t, ok)
+	protoResponse, ok := response.Content.Get("application/x-protobuf")
+	assert.True(t, ok)
+	assert.Same(t, ndjsonResponse.Schema, protoResponse.Schema)
+	_, ok = response.Content.Get("application/json")
+	assert.False(t, ok)
+
+	// An annotated 200 response without content gets the produced content of the output message
+	pathItem, ok = doc.Paths.PathItems.Get("/v1/preview")
+	require.True(t, ok)
+	response, ok = pathItem.Post.Responses.Codes.Get("200")
+	require.True(t, ok)
+	assert.Equal(t, "The preview", response.Description)
+	require.NotNil(t, response.Content)
+	assert.Equal(t, 1, response.Content.Len())
+	preview, ok := response.Content.Get("application/x-protobuf")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/ExportResponse", preview.Schema.GetReference())
+}
+
+func TestConvertToOpenAPI_Extensions(t *testing.T) {
+	logo, err := structpb.NewValue(map[string]interface{}{"url": "https://test.com/logo.png"})
+	require.NoError(t, err)
+
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Info: &options.Info{
+			Title:      "Test API",
+			Version:    "1.0.0",
+			Extensions: map[string]*structpb.Value{"x-logo": logo},
+			Contact: &options.Contact{
+				Name:       "API team",
+				Extensions: map[string]*structpb.Value{"x-slack": structpb.NewStringValue("#api")},
+			},
+			License: &options.License{
+				Name:       "MIT",
+				Extensions: map[string]*structpb.Value{"x-spdx": structpb.NewStringValue("MIT")},
+			},
+		},
+		SecuritySchemes: []*options.SecurityScheme{
+			{Type: "oauth2", Name: "oauth2", Flows: &options.OAuth2Flows{
+				ClientCredentials: &options.OAuth2Flow{
+					TokenUrl:   "https://auth.test.com/token",
+					Extensions: map[string]*structpb.Value{"x-token-ttl": structpb.NewNumberValue(3600)},
+				},
+			}},
+		},
+		Extensions: map[string]*structpb.Value{
+			"x-big":   structpb.NewNumberValue(1e20),
+			"x-ratio": structpb.NewNumberValue(0.5),
+			"x-tagGroups": structpb.NewListValue(&structpb.ListValue{
+				Values: []*structpb.Value{structpb.NewStringValue("users")},
+			}),
+		},
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "GetUser",
+						InputType:  "test.package.GetUserRequest",
+						OutputType: "test.package.GetUserRequest",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/users",
+						Operation: &options.Operation{
+							Extensions: map[string]*structpb.Value{
+								"x-kong-plugin": structpb.NewBoolValue(true),
+								"x-rate-limit":  structpb.NewNumberValue(100),
+							},
+						},
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{Name: "GetUserRequest"},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+
+	rendered, err := doc.Render()
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "x-tagGroups:\n    - users")
+	assert.Contains(t, string(rendered), "x-logo:\n        url: https://test.com/logo.png")
+	assert.Contains(t, string(rendered), "x-kong-plugin: true")
+	assert.Contains(t, string(rendered), "x-rate-limit: 100")
+	assert.Contains(t, string(rendered), "x-slack: '#api'")
+	assert.Contains(t, string(rendered), "x-spdx: MIT")
+	assert.Contains(t, string(rendered), "x-token-ttl: 3600")
+	// Integral numbers beyond int64 are not wrapped around
+	assert.Contains(t, string(rendered), "x-big: 100000000000000000000")
+	assert.Contains(t, string(rendered), "x-ratio: 0.5")
+
+	// Numbers YAML and JSON cannot represent are rejected
+	parsedFile.Info.License.Extensions["x-spdx"] = structpb.NewNumberValue(math.Inf(1))
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `invalid extension "x-spdx" on License: number +Inf is not finite`)
+	parsedFile.Info.License.Extensions["x-spdx"] = structpb.NewStringValue("MIT")
+	parsedFile.Extensions["x-nan"] = structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewNumberValue(math.NaN())}})
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `invalid extension "x-nan" on document: number NaN is not finite`)
+	delete(parsedFile.Extensions, "x-nan")
+
+	// Keys without the x- prefix are rejected
+	parsedFile.Services[0].Methods[0].Operation.Extensions["rate-limit"] = structpb.NewNumberValue(1)
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `invalid extension "rate-limit" on Operation`)
+}
+
+func TestConvertToOpenAPI_Webhooks(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "GetUser",
+						InputType:  "test.package.UserEvent",
+						OutputType: "test.package.UserEvent",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/users/{user_id}",
+					},
+					{
+						Name:       "UserCreated",
+						InputType:  "test.package.UserEvent",
+						OutputType: "google.protobuf.Empty",
+						Webhook:    &options.Webhook{Name: "userCreated"},
+					},
+				},
+			},
+			{
+				Name:    "NotificationService",
+				Webhook: &options.Webhook{Name: "ignored", Method: "put"},
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "Notify",
+						InputType:  "test.package.UserEvent",
+						OutputType: "test.package.Ack",
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{
+				Name:   "UserEvent",
+				Fields: []generator.ParsedField{{Name: "user_id", Type: "string", Number: 1}},
+			},
+			{
+				Name:   "Ack",
+				Fields: []generator.ParsedField{{Name: "received", Type: "bool", Number: 1}},
+			},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+
+	// Webhook methods are not documented as paths
+	assert.Equal(t, 1, doc.Paths.PathItems.Len())
+	require.NotNil(t, doc.Webhooks)
+	assert.Equal(t, 2, doc.Webhooks.Len())
+
+	// Method annotation: keyed by its name, POST by default, payload from the request message
+	userCreated, ok := doc.Webhooks.Get("userCreated")
+	require.True(t, ok)
+	require.NotNil(t, userCreated.Post)
+	assert.Equal(t, "UserCreated", userCreated.Post.OperationId)
+	require.NotNil(t, userCreated.Post.RequestBody)
+	assert.True(t, *userCreated.Post.RequestBody.Required)
+	payload, ok := userCreated.Post.RequestBody.Content.Get("application/json")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/UserEvent", payload.Schema.GetReference())
+	ack, ok := userCreated.Post.Responses.Codes.Get("200")
+	require.True(t, ok)
+	assert.Nil(t, ack.Content)
+
+	// Service annotation: every method keyed by its own name using the service HTTP method
+	notify, ok := doc.Webhooks.Get("Notify")
+	require.True(t, ok)
+	require.NotNil(t, notify.Put)
+	ack, ok = notify.Put.Responses.Codes.Get("200")
+	require.True(t, ok)
+	ackContent, ok := ack.Content.Get("application/json")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/Ack", ackContent.Schema.GetReference())
+
+	// Every method of a path item is supported, others are rejected
+	parsedFile.Services[1].Webhook.Method = "head"
+	doc, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+	notify, ok = doc.Webhooks.Get("Notify")
+	require.True(t, ok)
+	assert.NotNil(t, notify.Head)
+	assert.Nil(t, notify.Post)
+
+	parsedFile.Services[1].Webhook.Method = "pots"
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `webhook Notify: unsupported HTTP method "pots"`)
+}
+
+func TestConvertToOpenAPI_Callbacks(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Services: []generator.ParsedService{
+			{
+				Name: "ExportService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "StartExport",
+						InputType:  "test.package.StartExportRequest",
+						OutputType: "test.package.StartExportRequest",
+						HTTPMethod: "POST",
+						HTTPPath:   "/v1/exports",
+						HTTPBody:   "*",
+						Callbacks: []*options.Callback{
+							{
+								Name:       "exportDone",
+								Expression: "{$request.body#/callback_url}",
+								Rpc:        "test.package.ExportService.ExportDone",
+							},
+						},
+					},
+					{
+						Name:           "ExportDone",
+						InputType:      "test.package.ExportResult",
+						OutputType:     "google.protobuf.Empty",
+						CallbackTarget: true,
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{
+				Name:   "StartExportRequest",
+				Fields: []generator.ParsedField{{Name: "callback_url", Type: "string", Number: 1}},
+			},
+			{
+				Name:   "ExportResult",
+				Fields: []generator.ParsedField{{Name: "download_url", Type: "string", Number: 1}},
+			},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+
+	// The callback target, without an HTTP rule, is only documented as a callback
+	assert.Equal(t, 1, doc.Paths.PathItems.Len())
+	assert.Nil(t, doc.Webhooks)
+	pathItem, ok := doc.Paths.PathItems.Get("/v1/exports")
+	require.True(t, ok)
+	require.NotNil(t, pathItem.Post.Callbacks)
+	callback, ok := pathItem.Post.Callbacks.Get("exportDone")
+	require.True(t, ok)
+	callbackItem, ok := callback.Expression.Get("{$request.body#/callback_url}")
+	require.True(t, ok)
+	require.NotNil(t, callbackItem.Post)
+	assert.Equal(t, "ExportDone", callbackItem.Post.OperationId)
+	payload, ok := callbackItem.Post.RequestBody.Content.Get("application/json")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/ExportResult", payload.Schema.GetReference())
+
+	rendered, err := doc.Render()
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "callbacks:\n                exportDone:\n                    '{$request.body#/callback_url}':")
+
+	// Unknown RPCs are reported
+	parsedFile.Services[0].Methods[0].Callbacks[0].Rpc = "test.package.ExportService.Missing"
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `references unknown RPC "test.package.ExportService.Missing"`)
+}
+
+func TestConvertToOpenAPI_JSONSchemaDialect(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "ListUsers",
+						InputType:  "test.package.ListUsersRequest",
+						OutputType: "test.package.ListUsersRequest",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/users",
+						Operation:  &options.Operation{},
+						Parameters: []*options.Parameter{
+							{
+								Name: "page_size",
+								In:   "query",
+								Schema: &options.Schema{
+									Type:             "integer",
+									Nullable:         pointerTo(true),
+									Example:          "20",
+									Maximum:          100,
+									ExclusiveMaximum: true,
+									Minimum:          1,
+								},
+							},
+							{
+								Name: "kind",
+								In:   "query",
+								Schema: &options.Schema{
+									Type:          "string",
+									Const:         "user",
+									SchemaDialect: "https://json-schema.org/draft/2020-12/schema",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{Name: "ListUsersRequest"},
+		},
+	}
+
+	// The plugin parameter is used when the file does not set a dialect
+	doc, err := generator.ConvertToOpenAPI(parsedFile, &generator.Options{
+		JSONSchemaDialect: "https://spec.openapis.org/oas/3.1/dialect/base",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://spec.openapis.org/oas/3.1/dialect/base", doc.JsonSchemaDialect)
+
+	rendered, err := doc.Render()
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "jsonSchemaDialect: https://spec.openapis.org/oas/3.1/dialect/base")
+	assert.Contains(t, string(rendered), "type:\n                        - integer\n                        - \"null\"")
+	assert.Contains(t, string(rendered), "exclusiveMaximum: 100")
+	assert.Contains(t, string(rendered), "minimum: 1")
+	assert.Contains(t, string(rendered), "examples:\n                        - 20")
+	assert.Contains(t, string(rendered), "const: user")
+	assert.Contains(t, string(rendered), "$schema: https://json-schema.org/draft/2020-12/schema")
+	assert.NotContains(t, string(rendered), "nullable")
+	assert.NotContains(t, string(rendered), "maximum: 100")
+
+	// The file option takes precedence and must be an absolute URI
+	parsedFile.JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	doc, err = generator.ConvertToOpenAPI(parsedFile, &generator.Options{
+		JSONSchemaDialect: "https://spec.openapis.org/oas/3.1/dialect/base",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", doc.JsonSchemaDialect)
+
+	parsedFile.JSONSchemaDialect = "dialect/base"
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `invalid jsonSchemaDialect "dialect/base"`)
+}
+
+func pointerTo[T any](value T) *T {
+	return &value
+}
+
+func TestConvertToOpenAPI_OpenAPI30(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "ListUsers",
+						InputType:  "test.package.ListUsersRequest",
+						OutputType: "test.package.ListUsersRequest",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/users",
+						Operation:  &options.Operation{},
+						Parameters: []*options.Parameter{
+							{
+								Name: "page_size",
+								In:   "query",
+								Schema: &options.Schema{
+									Type:             "integer",
+									Nullable:         pointerTo(true),
+									Example:          "20",
+									Maximum:          100,
+									ExclusiveMaximum: true,
+								},
+							},
+							{
+								Name:   "kind",
+								In:     "query",
+								Schema: &options.Schema{Type: "string", Const: "user"},
+							},
+							{
+								Name:   "filter",
+								In:     "query",
+								Schema: &options.Schema{Ref: "#/components/schemas/ListUsersRequest", Nullable: pointerTo(true)},
+							},
+						},
+					},
+					{
+						Name:       "UserCreated",
+						InputType:  "test.package.ListUsersRequest",
+						OutputType: "google.protobuf.Empty",
+						Webhook:    &options.Webhook{},
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{Name: "ListUsersRequest"},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, &generator.Options{OpenAPIVersion: generator.OpenAPIVersion30})
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.3", doc.Version)
+	// Webhooks are kept until the document is written, where they move to x-webhooks
+	require.NotNil(t, doc.Webhooks)
+
+	rendered, err := doc.Render()
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "openapi: 3.0.3")
+	assert.Contains(t, string(rendered), "nullable: true")
+	assert.Contains(t, string(rendered), "maximum: 100")
+	assert.Contains(t, string(rendered), "exclusiveMaximum: true")
+	assert.Contains(t, string(rendered), "example: 20")
+	assert.Contains(t, string(rendered), "enum:\n                        - user")
+	assert.NotContains(t, string(rendered), "const:")
+	// nullable is ignored next to a reference, so the null value is an alternative of its own
+	assert.Contains(t, string(rendered), `anyOf:
+                        - $ref: '#/components/schemas/ListUsersRequest'
+                        - type: object
+                          enum:
+                            - null
+                          nullable: true`)
+
+	// jsonSchemaDialect only exists in OpenAPI 3.1
+	_, err = generator.ConvertToOpenAPI(parsedFile, &generator.Options{
+		OpenAPIVersion:    generator.OpenAPIVersion30,
+		JSONSchemaDialect: "https://spec.openapis.org/oas/3.1/dialect/base",
+	})
+	assert.ErrorContains(t, err, "requires OpenAPI 3.1")
+
+	_, err = generator.ConvertToOpenAPI(parsedFile, &generator.Options{OpenAPIVersion: "2.0"})
+	assert.ErrorContains(t, err, `unsupported OpenAPI version "2.0"`)
+}
+
+func TestConvertToOpenAPI_IncludePackageInTags(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "admin.v1",
+		Services: []generator.ParsedService{
+			{
+				Name: "AdminService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "GetSettings",
+						InputType:  "admin.v1.Settings",
+						OutputType: "admin.v1.Settings",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/settings",
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{
+				Name:   "Settings",
+				Fields: []generator.ParsedField{{Name: "name", Type: "string", Number: 1}},
+			},
+		},
+		Tags: []*options.Tag{
+			{Name: "AdminService", Description: "Administration operations"},
+			{Name: "internal"},
+		},
+	}
+
+	// Tags default to the bare service name
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+	pathItem, ok := doc.Paths.PathItems.Get("/v1/settings")
+	require.True(t, ok)
+	assert.Equal(t, []string{"AdminService"}, pathItem.Get.Tags)
+	assert.Equal(t, "AdminService", doc.Tags[0].Name)
+
+	// Operations and the matching tag definition are qualified with the package
+	doc, err = generator.ConvertToOpenAPI(parsedFile, &generator.Options{IncludePackageInTags: true})
+	require.NoError(t, err)
+	pathItem, ok = doc.Paths.PathItems.Get("/v1/settings")
+	require.True(t, ok)
+	assert.Equal(t, []string{"admin.v1.AdminService"}, pathItem.Get.Tags)
+	require.Len(t, doc.Tags, 2)
+	assert.Equal(t, "admin.v1.AdminService", doc.Tags[0].Name)
+	assert.Equal(t, "Administration operations", doc.Tags[0].Description)
+	assert.Equal(t, "internal", doc.Tags[1].Name)
+}
+
+func TestConvertToOpenAPI_NamingStrategy(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "a.v1",
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "GetUser",
+						InputType:  "a.v1.GetUserRequest",
+						OutputType: "a.v1.User",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/users/{id}",
+						Responses: []*options.Response{
+							{
+								Code: "200",
+								Content: map[string]*options.MediaType{
+									"application/json": {Schema: &options.Schema{Ref: "#/components/schemas/User"}},
+								},
+							},
+							{
+								Code: "404",
+								Content: map[string]*options.MediaType{
+									"application/json": {Schema: &options.Schema{Ref: "#/components/schemas/Group"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{
+				Name:   "GetUserRequest",
+				Fields: []generator.ParsedField{{Name: "id", Type: "string", Number: 1}},
+			},
+			{
+				Name: "User",
+				Fields: []generator.ParsedField{
+					{Name: "id", Type: "string", Number: 1},
+					{Name: "legacy", Type: "b.v2.User", Number: 2},
+					{Name: "group", Type: "a.v1.Group", Number: 3},
+				},
+			},
+			{
+				Name:   "Group",
+				Fields: []generator.ParsedField{{Name: "name", Type: "string", Number: 1}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		opts   *generator.Options
+		user   string
+		legacy string
+		group  string
+	}{
+		{
+			name:   "simple falls back to fully qualified names on collision",
+			opts:   &generator.Options{NamingStrategy: generator.NamingSimple},
+			user:   "a.v1.User",
+			legacy: "b.v2.User",
+			group:  "Group",
+		},
+		{
+			name:   "package",
+			opts:   &generator.Options{NamingStrategy: generator.NamingPackage},
+			user:   "v1.User",
+			legacy: "v2.User",
+			group:  "v1.Group",
+		},
+		{
+			name:   "fully qualified",
+			opts:   &generator.Options{NamingStrategy: generator.NamingFQN},
+			user:   "a.v1.User",
+			legacy: "b.v2.User",
+			group:  "a.v1.Group",
+		},
+		{
+			name:   "fqn_for_openapi_name",
+			opts:   &generator.Options{FQNForOpenAPIName: true},
+			user:   "a.v1.User",
+			legacy: "b.v2.User",
+			group:  "a.v1.Group",
+		},
+		{
+			name:   "shortest unique",
+			opts:   &generator.Options{NamingStrategy: generator.NamingUnique},
+			user:   "v1.User",
+			legacy: "v2.User",
+			group:  "Group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := generator.ConvertToOpenAPI(parsedFile, tt.opts)
+			require.NoError(t, err)
+
+			pathItem, ok := doc.Paths.PathItems.Get("/v1/users/{id}")
+			require.True(t, ok)
+
+			// References written in annotations point to the renamed component
+			okResponse, ok := pathItem.Get.Responses.Codes.Get("200")
+			require.True(t, ok)
+			okContent, ok := okResponse.Content.Get("application/json")
+			require.True(t, ok)
+			assert.Equal(t, "#/components/schemas/"+tt.user, okContent.Schema.GetReference())
+			notFound, ok := pathItem.Get.Responses.Codes.Get("404")
+			require.True(t, ok)
+			notFoundContent, ok := notFound.Content.Get("application/json")
+			require.True(t, ok)
+			assert.Equal(t, "#/components/schemas/"+tt.group, notFoundContent.Schema.GetReference())
+
+			// Messages of the file are stored under their component name, other types are only referenced
+			user, ok := doc.Components.Schemas.Get(tt.user)
+			require.True(t, ok)
+			_, ok = doc.Components.Schemas.Get(tt.legacy)
+			assert.False(t, ok)
+			legacy, ok := user.Schema().Properties.Get("legacy")
+			require.True(t, ok)
+			assert.Equal(t, "#/components/schemas/"+tt.legacy, legacy.GetReference())
+
+			_, ok = doc.Components.Schemas.Get(tt.group)
+			assert.True(t, ok)
+		})
+	}
+
+	_, err := generator.ConvertToOpenAPI(parsedFile, &generator.Options{NamingStrategy: "legacy"})
+	assert.ErrorContains(t, err, `unsupported naming strategy "legacy"`)
+}
+
+func TestConvertToOpenAPI_RecursiveMessage(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Services: []generator.ParsedService{
+			{
+				Name: "TreeService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "GetTree",
+						InputType:  "test.package.Node",
+						OutputType: "test.package.Node",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/tree",
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{
+				Name: "Node",
+				Fields: []generator.ParsedField{
+					{Name: "children", Type: "repeated test.package.Node", Number: 1},
+				},
+			},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+
+	node, ok := doc.Components.Schemas.Get("Node")
+	require.True(t, ok)
+	children, ok := node.Schema().Properties.Get("children")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/Node", children.Schema().Items.A.GetReference())
+}
+
+func TestConvertToOpenAPI_OperationIDs(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.v1",
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "Get",
+						InputType:  "google.protobuf.Empty",
+						OutputType: "google.protobuf.Empty",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/users/{id}",
+						AdditionalBindings: []generator.ParsedHTTPBinding{
+							{HTTPMethod: "GET", HTTPPath: "/v1/accounts/{id}"},
+						},
+					},
+				},
+			},
+			{
+				Name: "GroupService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "Get",
+						InputType:  "google.protobuf.Empty",
+						OutputType: "google.protobuf.Empty",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/groups/{id}",
+					},
+				},
+			},
+		},
+	}
+
+	operationIDs := func(doc *high.Document) []string {
+		var ids []string
+		for _, path := range []string{"/v1/users/{id}", "/v1/accounts/{id}", "/v1/groups/{id}"} {
+			pathItem, ok := doc.Paths.PathItems.Get(path)
+			require.True(t, ok, path)
+			ids = append(ids, pathItem.Get.OperationId)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		opts     *generator.Options
+		expected []string
+	}{
+		{
+			name:     "default template suffixes duplicates",
+			opts:     nil,
+			expected: []string{"Get", "Get_2", "Get_3"},
+		},
+		{
+			name:     "service and method",
+			opts:     &generator.Options{OperationIDTemplate: "{Service}_{Method}"},
+			expected: []string{"UserService_Get", "UserService_Get_2", "GroupService_Get"},
+		},
+		{
+			name:     "fully qualified in camel case",
+			opts:     &generator.Options{OperationIDTemplate: "{package}.{Service}.{Method}", OperationIDCase: generator.OperationIDCaseCamel},
+			expected: []string{"testV1UserServiceGet", "testV1UserServiceGet2", "testV1GroupServiceGet"},
+		},
+		{
+			name:     "snake case",
+			opts:     &generator.Options{OperationIDTemplate: "{Service}{Method}", OperationIDCase: generator.OperationIDCaseSnake},
+			expected: []string{"user_service_get", "user_service_get_2", "group_service_get"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := generator.ConvertToOpenAPI(parsedFile, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, operationIDs(doc))
+		})
+	}
+
+	// Annotated operation ids are kept and win over generated ones
+	parsedFile.Services[1].Methods[0].Operation = &options.Operation{OperationId: "Get"}
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Get_2", "Get_3", "Get"}, operationIDs(doc))
+
+	// Annotated operation ids must be unique
+	parsedFile.Services[0].Methods[0].Operation = &options.Operation{OperationId: "Get"}
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `duplicate operationId "Get" on methods UserService.Get and GroupService.Get`)
+
+	_, err = generator.ConvertToOpenAPI(parsedFile, &generator.Options{OperationIDTemplate: "{method}"})
+	assert.ErrorContains(t, err, `unsupported operationId template "{method}"`)
+	_, err = generator.ConvertToOpenAPI(parsedFile, &generator.Options{OperationIDCase: "kebab"})
+	assert.ErrorContains(t, err, `unsupported operationId case "kebab"`)
+}
+
+func TestConvertToOpenAPI_Streaming(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Services: []generator.ParsedService{
+			{
+				Name: "ChatService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:            "WatchMessages",
+						InputType:       "test.package.Message",
+						OutputType:      "test.package.Message",
+						HTTPMethod:      "GET",
+						HTTPPath:        "/v1/messages:watch",
+						ServerStreaming: true,
+					},
+					{
+						Name:            "SubscribeMessages",
+						InputType:       "test.package.Message",
+						OutputType:      "test.package.Message",
+						HTTPMethod:      "GET",
+						HTTPPath:        "/v1/messages:subscribe",
+						ServerStreaming: true,
+						Operation:       &options.Operation{Produces: []string{"text/event-stream"}},
+					},
+					{
+						Name:            "TailMessages",
+						InputType:       "test.package.Message",
+						OutputType:      "test.package.Message",
+						HTTPMethod:      "GET",
+						HTTPPath:        "/v1/messages:tail",
+						ServerStreaming: true,
+						Responses:       []*options.Response{{Code: "404", Description: "Not found"}},
+					},
+					{
+						Name:            "ReplayMessages",
+						InputType:       "test.package.Message",
+						OutputType:      "test.package.Message",
+						HTTPMethod:      "GET",
+						HTTPPath:        "/v1/messages:replay",
+						ServerStreaming: true,
+						Responses:       []*options.Response{{Code: "200", Description: "Replayed messages"}},
+					},
+					{
+						Name:            "UploadMessages",
+						InputType:       "test.package.Message",
+						OutputType:      "test.package.Message",
+						HTTPMethod:      "POST",
+						HTTPPath:        "/v1/messages:upload",
+						HTTPBody:        "*",
+						ClientStreaming: true,
+					},
+					{
+						Name:            "Chat",
+						InputType:       "test.package.Message",
+						OutputType:      "test.package.Message",
+						HTTPMethod:      "POST",
+						HTTPPath:        "/v1/messages:chat",
+						HTTPBody:        "*",
+						ClientStreaming: true,
+						ServerStreaming: true,
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{
+				Name:   "Message",
+				Fields: []generator.ParsedField{{Name: "text", Type: "string", Number: 1}},
+			},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+
+	// Server streams return newline-delimited result envelopes by default
+	watch, ok := doc.Paths.PathItems.Get("/v1/messages:watch")
+	require.True(t, ok)
+	watchResponse, ok := watch.Get.Responses.Codes.Get("200")
+	require.True(t, ok)
+	watchContent, ok := watchResponse.Content.Get("application/x-ndjson")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/StreamResultOfMessage", watchContent.Schema.GetReference())
+	assert.Equal(t, 1, watchResponse.Content.Len())
+
+	envelope, ok := doc.Components.Schemas.Get("StreamResultOfMessage")
+	require.True(t, ok)
+	result, ok := envelope.Schema().Properties.Get("result")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/Message", result.GetReference())
+	streamError, ok := envelope.Schema().Properties.Get("error")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/Status", streamError.GetReference())
+	_, ok = doc.Components.Schemas.Get("Status")
+	assert.True(t, ok)
+	assert.Nil(t, watch.Get.Extensions)
+
+	// Produced media types replace the default, e.g. for server-sent events
+	subscribe, ok := doc.Paths.PathItems.Get("/v1/messages:subscribe")
+	require.True(t, ok)
+	subscribeResponse, ok := subscribe.Get.Responses.Codes.Get("200")
+	require.True(t, ok)
+	subscribeContent, ok := subscribeResponse.Content.Get("text/event-stream")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/StreamResultOfMessage", subscribeContent.Schema.GetReference())
+
+	// Annotated error responses do not hide the stream
+	tail, ok := doc.Paths.PathItems.Get("/v1/messages:tail")
+	require.True(t, ok)
+	tailResponse, ok := tail.Get.Responses.Codes.Get("200")
+	require.True(t, ok)
+	tailContent, ok := tailResponse.Content.Get("application/x-ndjson")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/StreamResultOfMessage", tailContent.Schema.GetReference())
+	_, ok = tail.Get.Responses.Codes.Get("404")
+	assert.True(t, ok)
+
+	// An annotated 200 response replaces the stream documentation
+	replay, ok := doc.Paths.PathItems.Get("/v1/messages:replay")
+	require.True(t, ok)
+	replayResponse, ok := replay.Get.Responses.Codes.Get("200")
+	require.True(t, ok)
+	assert.Equal(t, "Replayed messages", replayResponse.Description)
+
+	// Client and bidirectional streams are flagged
+	upload, ok := doc.Paths.PathItems.Get("/v1/messages:upload")
+	require.True(t, ok)
+	kind, ok := upload.Post.Extensions.Get("x-grpc-streaming")
+	require.True(t, ok)
+	assert.Equal(t, "client", kind.Value)
+
+	chat, ok := doc.Paths.PathItems.Get("/v1/messages:chat")
+	require.True(t, ok)
+	kind, ok = chat.Post.Extensions.Get("x-grpc-streaming")
+	require.True(t, ok)
+	assert.Equal(t, "bidirectional", kind.Value)
+	chatResponse, ok := chat.Post.Responses.Codes.Get("200")
+	require.True(t, ok)
+	_, ok = chatResponse.Content.Get("application/x-ndjson")
+	assert.True(t, ok)
+}
+
+func TestConvertToOpenAPI_DefaultErrors(t *testing.T) {
+	newParsedFile := func() *generator.ParsedFile {
+		return &generator.ParsedFile{
+			Package: "test.package",
+			Services: []generator.ParsedService{
+				{
+					Name: "UserService",
+					Methods: []generator.ParsedMethod{
+						{
+							Name:       "GetUser",
+							InputType:  "test.package.User",
+							OutputType: "test.package.User",
+							HTTPMethod: "GET",
+							HTTPPath:   "/v1/users",
+						},
+						{
+							Name:       "DeleteUser",
+							InputType:  "test.package.User",
+							OutputType: "test.package.User",
+							HTTPMethod: "DELETE",
+							HTTPPath:   "/v1/users",
+							Responses: []*options.Response{
+								{Code: "default", Description: "Annotated error"},
+							},
+						},
+					},
+				},
+			},
+			Messages: []generator.ParsedMessage{
+				{Name: "User", Fields: []generator.ParsedField{{Name: "id", Type: "string", Number: 1}}},
+				{Name: "Problem", Fields: []generator.ParsedField{{Name: "title", Type: "string", Number: 1}}},
+			},
+		}
+	}
+
+	// Operations document google.rpc.Status errors by default
+	doc, err := generator.ConvertToOpenAPI(newParsedFile(), nil)
+	require.NoError(t, err)
+	users, ok := doc.Paths.PathItems.Get("/v1/users")
+	require.True(t, ok)
+	require.NotNil(t, users.Get.Responses.Default)
+	assert.Equal(t, "An unexpected error response.", users.Get.Responses.Default.Description)
+	content, ok := users.Get.Responses.Default.Content.Get("application/json")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/Status", content.Schema.GetReference())
+
+	status, ok := doc.Components.Schemas.Get("Status")
+	require.True(t, ok)
+	details, ok := status.Schema().Properties.Get("details")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/Any", details.Schema().Items.A.GetReference())
+	_, ok = doc.Components.Schemas.Get("Any")
+	assert.True(t, ok)
+
+	// An annotated default response is kept
+	assert.Nil(t, users.Delete.Responses.Default)
+	annotated, ok := users.Delete.Responses.Codes.Get("default")
+	require.True(t, ok)
+	assert.Equal(t, "Annotated error", annotated.Description)
+
+	// Default errors can be disabled
+	doc, err = generator.ConvertToOpenAPI(newParsedFile(), &generator.Options{DisableDefaultErrors: true})
+	require.NoError(t, err)
+	users, ok = doc.Paths.PathItems.Get("/v1/users")
+	require.True(t, ok)
+	assert.Nil(t, users.Get.Responses.Default)
+	_, ok = doc.Components.Schemas.Get("Status")
+	assert.False(t, ok)
+
+	// A custom message replaces google.rpc.Status
+	doc, err = generator.ConvertToOpenAPI(newParsedFile(), &generator.Options{DefaultErrorType: "test.package.Problem"})
+	require.NoError(t, err)
+	users, ok = doc.Paths.PathItems.Get("/v1/users")
+	require.True(t, ok)
+	require.NotNil(t, users.Get.Responses.Default)
+	content, ok = users.Get.Responses.Default.Content.Get("application/json")
+	require.True(t, ok)
+	assert.Equal(t, "#/components/schemas/Problem", content.Schema.GetReference())
+	_, ok = doc.Components.Schemas.Get("Status")
+	assert.False(t, ok)
+
+	rendered, err := doc.Render()
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "default:\n                    description: An unexpected error response.")
+}
+
+func TestConvertToOpenAPI_Components(t *testing.T) {
+	newParsedFile := func(ref string) *generator.ParsedFile {
+		return &generator.ParsedFile{
+			Package: "test.package",
+			Components: &options.Components{
+				Responses: map[string]*options.Response{
+					"NotFound": {
+						Description: "Resource not found",
+						Headers:     map[string]*options.Header{"X-Request-Id": {Ref: "RequestId"}},
+					},
+				},
+				Parameters: map[string]*options.Parameter{
+					"UserId": {Name: "user_id", In: "path", Required: pointerTo(true), Schema: &options.Schema{Type: "string"}},
+				},
+				Headers: map[string]*options.Header{
+					"RequestId": {Description: "Identifier of the request", Schema: &options.Schema{Type: "string"}},
+				},
+				Examples: map[string]*options.Example{
+					"Alice": {Summary: "A user", Value: "alice"},
+				},
+				RequestBodies: map[string]*options.RequestBody{
+					"User": {
+						Description: "The user to create",
+						Required:    true,
+						Content: map[string]*options.MediaType{
+							"application/json": {
+								Schema:   &options.Schema{Ref: "#/components/schemas/User"},
+								Examples: map[string]*options.Example{"alice": {Ref: "Alice"}},
+							},
+						},
+					},
+				},
+				Links: map[string]*options.Link{
+					"GetUser": {OperationId: "GetUser", Parameters: map[string]string{"user_id": "$response.body#/id"}},
+				},
+			},
+			Services: []generator.ParsedService{
+				{
+					Name: "UserService",
+					Methods: []generator.ParsedMethod{
+						{
+							Name:       "GetUser",
+							InputType:  "test.package.User",
+							OutputType: "test.package.User",
+							HTTPMethod: "GET",
+							HTTPPath:   "/v1/users/{user_id}",
+							Operation:  &options.Operation{},
+							Parameters: []*options.Parameter{{Ref: "UserId"}},
+							Responses: []*options.Response{
+								{Code: "404", Ref: ref},
+							},
+						},
+						{
+							Name:        "CreateUser",
+							InputType:   "test.package.User",
+							OutputType:  "test.package.User",
+							HTTPMethod:  "POST",
+							HTTPPath:    "/v1/users",
+							HTTPBody:    "*",
+							RequestBody: &options.RequestBody{Ref: "User"},
+							Responses: []*options.Response{
+								{
+									Code:        "201",
+									Description: "User created",
+									Links: map[string]*options.Link{
+										"ListUsers": {OperationId: "ListUsers"},
+										"GetUser":   {Ref: "#/components/links/GetUser"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Messages: []generator.ParsedMessage{
+				{Name: "User", Fields: []generator.ParsedField{{Name: "user_id", Type: "string", Number: 1}}},
+			},
+		}
+	}
+
+	doc, err := generator.ConvertToOpenAPI(newParsedFile("NotFound"), nil)
+	require.NoError(t, err)
+
+	// Components are declared once, keyed by name
+	notFound, ok := doc.Components.Responses.Get("NotFound")
+	require.True(t, ok)
+	assert.Equal(t, "Resource not found", notFound.Description)
+	_, ok = doc.Components.Parameters.Get("UserId")
+	assert.True(t, ok)
+	_, ok = doc.Components.Headers.Get("RequestId")
+	assert.True(t, ok)
+	_, ok = doc.Components.Examples.Get("Alice")
+	assert.True(t, ok)
+	_, ok = doc.Components.RequestBodies.Get("User")
+	assert.True(t, ok)
+	_, ok = doc.Components.Links.Get("GetUser")
+	assert.True(t, ok)
+
+	// A referenced path parameter is not documented a second time
+	user, ok := doc.Paths.PathItems.Get("/v1/users/{user_id}")
+	require.True(t, ok)
+	assert.Len(t, user.Get.Parameters, 1)
+
+	// Nor is one referenced by JSON pointer
+	pointerFile := newParsedFile("NotFound")
+	pointerFile.Services[0].Methods[0].Parameters = []*options.Parameter{{Ref: "#/components/parameters/UserId"}}
+	pointerDoc, err := generator.ConvertToOpenAPI(pointerFile, nil)
+	require.NoError(t, err)
+	pointerUser, ok := pointerDoc.Paths.PathItems.Get("/v1/users/{user_id}")
+	require.True(t, ok)
+	assert.Len(t, pointerUser.Get.Parameters, 1)
+
+	rendered, err := doc.Render()
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "- $ref: '#/components/parameters/UserId'")
+	assert.Contains(t, string(rendered), "\"404\":\n                    $ref: '#/components/responses/NotFound'")
+	assert.Contains(t, string(rendered), "requestBody:\n                $ref: '#/components/requestBodies/User'")
+	assert.Contains(t, string(rendered), "X-Request-Id:\n                    $ref: '#/components/headers/RequestId'")
+	assert.Contains(t, string(rendered), "alice:\n                            $ref: '#/components/examples/Alice'")
+	// Links are rendered in name order
+	assert.Contains(t, string(rendered), "GetUser:\n                            $ref: '#/components/links/GetUser'\n"+
+		"                        ListUsers:\n                            operationId: ListUsers")
+
+	// Names must match a declared component
+	_, err = generator.ConvertToOpenAPI(newParsedFile("Missing"), nil)
+	assert.ErrorContains(t, err, `unknown responses component "Missing"`)
+}
+
+func TestConvertToOpenAPI_DefaultResponses(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		DefaultResponses: []*options.Response{
+			{Code: "401", Description: "Unauthenticated"},
+			{Code: "429", Description: "Too many requests"},
+			{Code: "500", Description: "Internal error"},
+		},
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				DefaultResponses: []*options.Response{
+					{Code: "429", Description: "User quota exceeded"},
+				},
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "GetUser",
+						InputType:  "test.package.User",
+						OutputType: "test.package.User",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/users",
+						Responses: []*options.Response{
+							{Code: "200", Description: "The user"},
+							{Code: "500", Description: "Database unavailable"},
+						},
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{Name: "User", Fields: []generator.ParsedField{{Name: "id", Type: "string", Number: 1}}},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+
+	users, ok := doc.Paths.PathItems.Get("/v1/users")
+	require.True(t, ok)
+	descriptions := make(map[string]string)
+	for code, response := range users.Get.Responses.Codes.FromOldest() {
+		descriptions[code] = response.Description
+	}
+	assert.Equal(t, map[string]string{
+		"200": "The user",
+		"401": "Unauthenticated",
+		"429": "User quota exceeded",
+		"500": "Database unavailable",
+	}, descriptions)
+
+	// Default responses must have a code
+	parsedFile.Services[0].DefaultResponses = []*options.Response{{Description: "No code"}}
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, "default response of service UserService requires a code")
+}
+
+func TestConvertToOpenAPI_SecuritySchemeNames(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		SecuritySchemes: []*options.SecurityScheme{
+			{Type: "apiKey", Name: "headerKey", ParameterName: "X-API-Key", In: "header"},
+			{Type: "apiKey", Name: "queryKey", ParameterName: "api_key", In: "query"},
+			{Type: "http", Scheme: "bearer"},
+		},
+		Security: []*options.SecurityRequirement{{Name: "headerKey"}, {Name: "queryKey"}, {Name: "http"}},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+
+	// Schemes are keyed by name, falling back to their type
+	assert.Equal(t, 3, doc.Components.SecuritySchemes.Len())
+	headerKey, ok := doc.Components.SecuritySchemes.Get("headerKey")
+	require.True(t, ok)
+	assert.Equal(t, "X-API-Key", headerKey.Name)
+	assert.Equal(t, "header", headerKey.In)
+	queryKey, ok := doc.Components.SecuritySchemes.Get("queryKey")
+	require.True(t, ok)
+	assert.Equal(t, "api_key", queryKey.Name)
+	assert.Equal(t, "query", queryKey.In)
+	bearer, ok := doc.Components.SecuritySchemes.Get("http")
+	require.True(t, ok)
+	assert.Empty(t, bearer.Name)
+
+	// Requirements must reference a declared scheme
+	parsedFile.Security = []*options.SecurityRequirement{{Name: "apiKey"}}
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `security requirement of file test.package references unknown security scheme "apiKey"`)
+
+	// Scheme names must be unique
+	parsedFile.Security = nil
+	parsedFile.SecuritySchemes = append(parsedFile.SecuritySchemes, &options.SecurityScheme{Type: "http", Scheme: "basic"})
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `duplicate security scheme "http"`)
+}
+
+func TestConvertToOpenAPI_SecurityRequirements(t *testing.T) {
+	newParsedFile := func() *generator.ParsedFile {
+		return &generator.ParsedFile{
+			Package: "test.package",
+			SecuritySchemes: []*options.SecurityScheme{
+				{Type: "apiKey", Name: "apiKey", ParameterName: "X-API-Key", In: "header"},
+				{Type: "mutualTLS", Name: "mtls"},
+				{Type: "oauth2", Name: "oauth2", Flows: &options.OAuth2Flows{
+					ClientCredentials: &options.OAuth2Flow{TokenUrl: "https://auth.test.com/token"},
+				}},
+			},
+			Security: []*options.SecurityRequirement{
+				// apiKey AND mtls, OR oauth2, OR no authentication
+				{Name: "apiKey", Schemes: []*options.SecuritySchemeScopes{{Name: "mtls"}}},
+				{Name: "oauth2", Scopes: []string{"read"}},
+				{},
+			},
+			Services: []generator.ParsedService{
+				{
+					Name: "HealthService",
+					Methods: []generator.ParsedMethod{
+						{
+							Name:       "Check",
+							InputType:  "test.package.Health",
+							OutputType: "test.package.Health",
+							HTTPMethod: "GET",
+							HTTPPath:   "/healthz",
+							Operation:  &options.Operation{NoSecurity: true},
+						},
+					},
+				},
+			},
+			Messages: []generator.ParsedMessage{{Name: "Health"}},
+		}
+	}
+
+	doc, err := generator.ConvertToOpenAPI(newParsedFile(), nil)
+	require.NoError(t, err)
+
+	require.Len(t, doc.Security, 3)
+	combined, ok := doc.Security[0].Requirements.Get("apiKey")
+	require.True(t, ok)
+	assert.Empty(t, combined)
+	_, ok = doc.Security[0].Requirements.Get("mtls")
+	assert.True(t, ok)
+	scopes, ok := doc.Security[1].Requirements.Get("oauth2")
+	require.True(t, ok)
+	assert.Equal(t, []string{"read"}, scopes)
+	assert.True(t, doc.Security[2].ContainsEmptyRequirement)
+
+	// no_security removes the global requirements from the operation
+	health, ok := doc.Paths.PathItems.Get("/healthz")
+	require.True(t, ok)
+	assert.NotNil(t, health.Get.Security)
+	assert.Empty(t, health.Get.Security)
+
+	rendered, err := doc.Render()
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "security:\n    - apiKey: []\n      mtls: []\n    - oauth2:\n        - read\n    - {}\n")
+	assert.Contains(t, string(rendered), "security: []")
+
+	// Combined schemes must be declared
+	parsedFile := newParsedFile()
+	parsedFile.Security[0].Schemes[0].Name = "mTLS"
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, `references unknown security scheme "mTLS"`)
+
+	// An operation cannot both require and remove security
+	parsedFile = newParsedFile()
+	parsedFile.Services[0].Methods[0].Security = []*options.SecurityRequirement{{Name: "oauth2"}}
+	_, err = generator.ConvertToOpenAPI(parsedFile, nil)
+	assert.ErrorContains(t, err, "method HealthService.Check cannot set both security requirements and no_security")
+}
+
+func TestConvertToOpenAPI_SecuritySchemeTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  *options.SecurityScheme
+		version generator.OpenAPIVersion
+		err     string
+	}{
+		{
+			name:   "api key in cookie",
+			scheme: &options.SecurityScheme{Type: "apiKey", ParameterName: "session", In: "cookie"},
+		},
+		{
+			name:   "api key without location",
+			scheme: &options.SecurityScheme{Type: "apiKey", ParameterName: "X-API-Key"},
+			err:    `security scheme "apiKey" of type apiKey must be in header, query or cookie, not ""`,
+		},
+		{
+			name:   "api key without parameter name",
+			scheme: &options.SecurityScheme{Type: "apiKey", In: "header"},
+			err:    `security scheme "apiKey" of type apiKey requires a parameter_name`,
+		},
+		{
+			name:   "http bearer",
+			scheme: &options.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		},
+		{
+			name:   "http digest",
+			scheme: &options.SecurityScheme{Type: "http", Scheme: "digest"},
+		},
+		{
+			name:   "http without scheme",
+			scheme: &options.SecurityScheme{Type: "http"},
+			err:    `security scheme "http" of type http requires a scheme`,
+		},
+		{
+			name:   "bearer format on basic",
+			scheme: &options.SecurityScheme{Type: "http", Scheme: "basic", BearerFormat: "JWT"},
+			err:    `security scheme "http" sets a bearer_format but its http scheme is "basic", not bearer`,
+		},
+		{
+			name:   "bearer format on api key",
+			scheme: &options.SecurityScheme{Type: "apiKey", ParameterName: "X-API-Key", In: "header", BearerFormat: "JWT"},
+			err:    `security scheme "apiKey" of type apiKey cannot set bearer_format`,
+		},
+		{
+			name:   "oauth2 without flows",
+			scheme: &options.SecurityScheme{Type: "oauth2"},
+			err:    `security scheme "oauth2" of type oauth2 requires at least one flow`,
+		},
+		{
+			name: "oauth2 authorization code without token url",
+			scheme: &options.SecurityScheme{Type: "oauth2", Flows: &options.OAuth2Flows{
+				AuthorizationCode: &options.OAuth2Flow{AuthorizationUrl: "https://auth.test.com/authorize"},
+			}},
+			err: `authorizationCode flow of security scheme "oauth2" requires a token_url`,
+		},
+		{
+			name:   "open id connect",
+			scheme: &options.SecurityScheme{Type: "openIdConnect", OpenIdConnectUrl: "https://auth.test.com/.well-known/openid-configuration"},
+		},
+		{
+			name:   "open id connect without url",
+			scheme: &options.SecurityScheme{Type: "openIdConnect"},
+			err:    `security scheme "openIdConnect" of type openIdConnect requires an open_id_connect_url`,
+		},
+		{
+			name:   "flows on open id connect",
+			scheme: &options.SecurityScheme{Type: "openIdConnect", OpenIdConnectUrl: "https://auth.test.com", Flows: &options.OAuth2Flows{}},
+			err:    `security scheme "openIdConnect" of type openIdConnect cannot set flows`,
+		},
+		{
+			name:   "mutual tls",
+			scheme: &options.SecurityScheme{Type: "mutualTLS"},
+		},
+		{
+			name:    "mutual tls in 3.0",
+			scheme:  &options.SecurityScheme{Type: "mutualTLS"},
+			version: generator.OpenAPIVersion30,
+			err:     `security scheme "mutualTLS" of type mutualTLS requires OpenAPI 3.1`,
+		},
+		{
+			name:   "location on mutual tls",
+			scheme: &options.SecurityScheme{Type: "mutualTLS", In: "header"},
+			err:    `security scheme "mutualTLS" of type mutualTLS cannot set in`,
+		},
+		{
+			name:   "unknown type",
+			scheme: &options.SecurityScheme{Type: "basic"},
+			err:    `security scheme "basic" has unsupported type "basic"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsedFile := &generator.ParsedFile{
+				Package:         "test.package",
+				SecuritySchemes: []*options.SecurityScheme{tt.scheme},
+			}
+
+			doc, err := generator.ConvertToOpenAPI(parsedFile, &generator.Options{OpenAPIVersion: tt.version})
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			scheme, ok := doc.Components.SecuritySchemes.Get(tt.scheme.GetType())
+			require.True(t, ok)
+			assert.Equal(t, tt.scheme.GetType(), scheme.Type)
+			_, err = doc.Render()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestConvertToOpenAPI_SchemaAnnotations(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				Methods: []generator.ParsedMethod{
+					{
+						Name:       "GetUser",
+						InputType:  "test.package.User",
+						OutputType: "test.package.User",
+						HTTPMethod: "GET",
+						HTTPPath:   "/v1/user",
+					},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{
+				Name:    "User",
+				Comment: "A user",
+				Schema: &options.Schema{
+					Title:    "User",
+					Required: []string{"id"},
+					Example:  `{"id": "123"}`,
+					ExternalDocs: &options.ExternalDocumentation{
+						Url:        "https://test.com/docs/users",
+						Extensions: map[string]*structpb.Value{"x-audience": structpb.NewStringValue("public")},
+					},
+				},
+				Fields: []generator.ParsedField{
+					{
+						Name: "id", Type: "string", Number: 1,
+						Schema: &options.Schema{Title: "ID", ReadOnly: proto.Bool(true), Example: `"123"`},
+					},
+					{
+						Name: "email", Type: "optional string", Number: 2,
+						Schema: &options.Schema{Format: "email", Pattern: "^.+@.+$", Required: []string{"email"}},
+					},
+					{
+						Name: "address", Type: "test.package.Address", Number: 3,
+						Schema: &options.Schema{Description: "Postal address"},
+					},
+					{Name: "nickname", Type: "string", Number: 4},
+				},
+			},
+			{
+				Name: "Address",
+				Fields: []generator.ParsedField{
+					{Name: "city", Type: "string", Number: 1},
+				},
+			},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, nil)
+	require.NoError(t, err)
+
+	user, ok := doc.Components.Schemas.Get("User")
+	require.True(t, ok)
+	schema := user.Schema()
+	assert.Equal(t, "User", schema.Title)
+	assert.Equal(t, "A user", schema.Description)
+	require.NotNil(t, schema.ExternalDocs)
+	audience, ok := schema.ExternalDocs.Extensions.Get("x-audience")
+	require.True(t, ok)
+	assert.Equal(t, "public", audience.Value)
+	require.Len(t, schema.Examples, 1)
+	assert.Equal(t, yaml.MappingNode, schema.Examples[0].Kind)
+
+	// The message annotation replaces the required list, field annotations add to it
+	assert.Equal(t, []string{"id", "email"}, schema.Required)
+
+	id, ok := schema.Properties.Get("id")
+	require.True(t, ok)
+	assert.Equal(t, "ID", id.Schema().Title)
+	assert.True(t, *id.Schema().ReadOnly)
+	require.Len(t, id.Schema().Examples, 1)
+	assert.Equal(t, "123", id.Schema().Examples[0].Value)
+	assert.Equal(t, yaml.DoubleQuotedStyle, id.Schema().Examples[0].Style)
+
+	email, ok := schema.Properties.Get("email")
+	require.True(t, ok)
+	assert.Equal(t, "email", email.Schema().Format)
+	assert.Equal(t, "^.+@.+$", email.Schema().Pattern)
+	assert.Empty(t, email.Schema().Required)
+
+	// Annotated references are wrapped so that the referenced component stays untouched
+	address, ok := schema.Properties.Get("address")
+	require.True(t, ok)
+	assert.False(t, address.IsReference())
+	assert.Equal(t, "Postal address", address.Schema().Description)
+	require.Len(t, address.Schema().AllOf, 1)
+	assert.Equal(t, "#/components/schemas/Address", address.Schema().AllOf[0].GetReference())
+
+	// Properties are rendered in a stable order
+	var names []string
+	for name := range schema.Properties.KeysFromOldest() {
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"address", "email", "id", "nickname"}, names)
+}
+
+func TestConvertToOpenAPI_ServiceTags(t *testing.T) {
+	parsedFile := &generator.ParsedFile{
+		Package: "test.package",
+		Tags:    []*options.Tag{{Name: "GroupService", Description: "Documented by the file"}},
+		Services: []generator.ParsedService{
+			{
+				Name: "UserService",
+				Tag: &options.Tag{
+					Name:         "users",
+					Description:  "User management",
+					ExternalDocs: &options.ExternalDocumentation{Url: "https://test.com/docs/users"},
+				},
+				Methods: []generator.ParsedMethod{
+					{Name: "GetUser", InputType: "test.package.User", OutputType: "test.package.User", HTTPMethod: "GET", HTTPPath: "/v1/user"},
+					{
+						Name: "ListUsers", InputType: "test.package.User", OutputType: "test.package.User", HTTPMethod: "GET", HTTPPath: "/v1/users",
+						Operation: &options.Operation{Tags: []string{"search"}},
+					},
+				},
+			},
+			{
+				Name: "GroupService",
+				Tag:  &options.Tag{Description: "Documented by the service"},
+				Methods: []generator.ParsedMethod{
+					{Name: "GetGroup", InputType: "test.package.User", OutputType: "test.package.User", HTTPMethod: "GET", HTTPPath: "/v1/group"},
+				},
+			},
+		},
+		Messages: []generator.ParsedMessage{
+			{Name: "User", Fields: []generator.ParsedField{{Name: "status", Type: "test.package.State", Number: 1}}},
+		},
+		Enums: []generator.ParsedEnum{
+			{
+				Name:    "State",
+				Comment: "Status of a user",
+				Values:  []generator.ParsedEnumValue{{Name: "UNKNOWN"}, {Name: "ACTIVE", Number: 1}},
+				Schema:  &options.Schema{Title: "State", Description: "Account status", Default: "ACTIVE"},
+			},
+		},
+	}
+
+	doc, err := generator.ConvertToOpenAPI(parsedFile, &generator.Options{IncludePackageInTags: true})
+	require.NoError(t, err)
+
+	// The tag annotation names the tag of the service operations, unless the operation sets its tags
+	pathItem, ok := doc.Paths.PathItems.Get("/v1/user")
+	require.True(t, ok)
+	assert.Equal(t, []string{"users"}, pathItem.Get.Tags)
+	pathItem, ok = doc.Paths.PathItems.Get("/v1/users")
+	require.True(t, ok)
+	assert.Equal(t, []string{"search"}, pathItem.Get.Tags)
+	pathItem, ok = doc.Paths.PathItems.Get("/v1/group")
+	require.True(t, ok)
+	assert.Equal(t, []string{"test.package.GroupService"}, pathItem.Get.Tags)
+
+	// Service tags are documented at the top level, the file tags taking precedence
+	require.Len(t, doc.Tags, 2)
+	assert.Equal(t, "test.package.GroupService", doc.Tags[0].Name)
+	assert.Equal(t, "Documented by the file", doc.Tags[0].Description)
+	assert.Equal(t, "users", doc.Tags[1].Name)
+	assert.Equal(t, "User management", doc.Tags[1].Description)
+	assert.Equal(t, "https://test.com/docs/users", doc.Tags[1].ExternalDocs.URL)
+
+	// The enum annotation documents the enum schema
+	state, ok := doc.Components.Schemas.Get("State")
+	require.True(t, ok)
+	assert.Equal(t, "State", state.Schema().Title)
+	assert.Equal(t, "Account status", state.Schema().Description)
+	assert.Equal(t, "ACTIVE", state.Schema().Default.Value)
+	assert.Len(t, state.Schema().Enum, 2)
+}
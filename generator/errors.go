@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+
+	high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+
+	"github.com/sapk/protoc-gen-openapiv3/options"
+)
+
+const (
+	// statusType is the message grpc-gateway uses to report errors, including inside response streams
+	statusType = "google.rpc.Status"
+	// anyType is the message carrying the error details of a google.rpc.Status
+	anyType = "google.protobuf.Any"
+)
+
+// addStatusTypes adds the types of the google.rpc.Status error model to a list of types
+func addStatusTypes(types map[string]string) {
+	types[statusType] = "google.rpc"
+	types[anyType] = "google.protobuf"
+}
+
+// addErrorTypes adds the types referenced by the default error response to a list of types
+func addErrorTypes(types map[string]string, opts *Options) {
+	switch {
+	case opts.DisableDefaultErrors:
+	case opts.DefaultErrorType == "":
+		addStatusTypes(types)
+	default:
+		errorType := opts.DefaultErrorType
+		if _, exists := types[errorType]; !exists && strings.Contains(errorType, ".") {
+			types[errorType] = errorType[:strings.LastIndex(errorType, ".")]
+		}
+	}
+}
+
+// usesDefaultErrorResponse reports whether an operation of the file gets the default error response,
+// because neither the operation nor the default responses of its service and file document a default response
+func usesDefaultErrorResponse(parsedFile *ParsedFile, opts *Options) bool {
+	hasDefault := func(responses []*options.Response) bool {
+		return slices.ContainsFunc(responses, func(resp *options.Response) bool {
+			return resp.GetCode() == "default"
+		})
+	}
+	if opts.DisableDefaultErrors || hasDefault(parsedFile.DefaultResponses) {
+		return false
+	}
+
+	for _, service := range parsedFile.Services {
+		if hasDefault(service.DefaultResponses) {
+			continue
+		}
+		for _, method := range service.Methods {
+			// Webhooks and callbacks do not get the default error response
+			if method.callbackOnly() || webhookFor(service, method) != nil {
+				continue
+			}
+			if !hasDefault(method.Operation.GetResponses()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addDefaultErrorResponse adds a default response describing the errors of the operation,
+// unless disabled or already annotated
+func addDefaultErrorResponse(parsedFile *ParsedFile, operation *high.Operation, doc *high.Document, opts *Options) {
+	if opts.DisableDefaultErrors {
+		return
+	}
+	if _, exists := operation.Responses.Codes.Get("default"); exists || operation.Responses.Default != nil {
+		return
+	}
+
+	var schema *options.Schema
+	if opts.DefaultErrorType != "" {
+		schema = convertMessageToSchema(parsedFile, opts.DefaultErrorType, doc)
+	} else {
+		schema = statusSchema(parsedFile, doc)
+	}
+
+	content := orderedmap.New[string, *high.MediaType]()
+	content.Set("application/json", &high.MediaType{
+		Schema: convertSchemaToOpenAPI(schema, doc),
+	})
+	operation.Responses.Default = &high.Response{
+		Description: "An unexpected error response.",
+		Content:     content,
+	}
+}
+
+// statusSchema returns a reference to the google.rpc.Status schema, adding it to components when missing
+func statusSchema(parsedFile *ParsedFile, doc *high.Document) *options.Schema {
+	name := schemaName(parsedFile, statusType)
+	if _, exists := doc.Components.Schemas.Get(name); !exists {
+		doc.Components.Schemas.Set(name, convertSchemaToOpenAPI(&options.Schema{
+			Type:        "object",
+			Description: "The error model of the API, defined by the google.rpc.Status message",
+			Properties: map[string]*options.Schema{
+				"code": {
+					Type:        "integer",
+					Format:      "int32",
+					Description: "The status code, which should be an enum value of google.rpc.Code",
+				},
+				"message": {
+					Type:        "string",
+					Description: "A developer-facing error message",
+				},
+				"details": {
+					Type:        "array",
+					Description: "A list of messages that carry the error details",
+					Items:       anySchema(parsedFile, doc),
+				},
+			},
+		}, doc))
+	}
+
+	return &options.Schema{Ref: "#/components/schemas/" + name}
+}
+
+// anySchema returns a reference to the google.protobuf.Any schema, adding it to components when missing
+func anySchema(parsedFile *ParsedFile, doc *high.Document) *options.Schema {
+	name := schemaName(parsedFile, anyType)
+	if _, exists := doc.Components.Schemas.Get(name); !exists {
+		doc.Components.Schemas.Set(name, convertSchemaToOpenAPI(&options.Schema{
+			Type:        "object",
+			Description: "Contains an arbitrary serialized message along with a @type that describes the type of the serialized message",
+			Properties: map[string]*options.Schema{
+				"@type": {
+					Type:        "string",
+					Description: "A URL/resource name that uniquely identifies the type of the serialized message",
+				},
+			},
+			AdditionalProperties: &options.Schema_AllowAdditional{AllowAdditional: true},
+		}, doc))
+	}
+
+	return &options.Schema{Ref: "#/components/schemas/" + name}
+}
@@ -0,0 +1,174 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/orderedmap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+)
+
+// extensionPrefix is the prefix every OpenAPI specification extension key must start with
+const extensionPrefix = "x-"
+
+// validateExtensionKeys checks that every key of an extensions map begins with "x-" and that its value
+// can be rendered
+func validateExtensionKeys(extensions map[string]*structpb.Value, owner string) error {
+	for key, value := range extensions {
+		if !strings.HasPrefix(key, extensionPrefix) {
+			return fmt.Errorf("invalid extension %q on %s: extension keys must begin with %q", key, owner, extensionPrefix)
+		}
+		if err := validateExtensionValue(value); err != nil {
+			return fmt.Errorf("invalid extension %q on %s: %w", key, owner, err)
+		}
+	}
+	return nil
+}
+
+// validateExtensionValue rejects the numbers YAML and JSON cannot represent, NaN and infinities
+func validateExtensionValue(value *structpb.Value) error {
+	switch kind := value.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		if math.IsNaN(kind.NumberValue) || math.IsInf(kind.NumberValue, 0) {
+			return fmt.Errorf("number %v is not finite", kind.NumberValue)
+		}
+	case *structpb.Value_ListValue:
+		for _, item := range kind.ListValue.GetValues() {
+			if err := validateExtensionValue(item); err != nil {
+				return err
+			}
+		}
+	case *structpb.Value_StructValue:
+		for _, field := range kind.StructValue.GetFields() {
+			if err := validateExtensionValue(field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateExtensions walks the given option messages, including nested ones,
+// and checks the keys of every extensions map they carry
+func validateExtensions(msgs ...proto.Message) error {
+	for _, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+		if err := validateMessageExtensions(msg.ProtoReflect()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMessageExtensions is the recursive part of validateExtensions
+func validateMessageExtensions(msg protoreflect.Message) error {
+	if !msg.IsValid() {
+		return nil
+	}
+
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap() && fd.Name() == "extensions":
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				if !strings.HasPrefix(k.String(), extensionPrefix) {
+					err = fmt.Errorf("invalid extension %q on %s: extension keys must begin with %q",
+						k.String(), msg.Descriptor().Name(), extensionPrefix)
+				} else if value, ok := mv.Message().Interface().(*structpb.Value); ok {
+					if valueErr := validateExtensionValue(value); valueErr != nil {
+						err = fmt.Errorf("invalid extension %q on %s: %w", k.String(), msg.Descriptor().Name(), valueErr)
+					}
+				}
+				return err == nil
+			})
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				err = validateMessageExtensions(mv.Message())
+				return err == nil
+			})
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len() && err == nil; i++ {
+				err = validateMessageExtensions(v.List().Get(i).Message())
+			}
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			err = validateMessageExtensions(v.Message())
+		}
+		return err == nil
+	})
+
+	return err
+}
+
+// convertExtensions converts specification extensions to their OpenAPI representation.
+// Keys are sorted so the output is stable across runs.
+func convertExtensions(extensions map[string]*structpb.Value) *orderedmap.Map[string, *yaml.Node] {
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(extensions))
+	for key := range extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	converted := orderedmap.New[string, *yaml.Node]()
+	for _, key := range keys {
+		converted.Set(key, convertValueToYAML(extensions[key]))
+	}
+	return converted
+}
+
+// convertValueToYAML converts a protobuf Value to a YAML node
+func convertValueToYAML(value *structpb.Value) *yaml.Node {
+	switch kind := value.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(kind.BoolValue)}
+	case *structpb.Value_NumberValue:
+		// Integral numbers are written as integers, formatted from the float so none overflows int64.
+		// math.MaxInt64 rounds up to 2^63 as a float, beyond int64 the number is left untagged.
+		number := kind.NumberValue
+		if number == math.Trunc(number) {
+			node := &yaml.Node{Kind: yaml.ScalarNode, Value: strconv.FormatFloat(number, 'f', -1, 64)}
+			if number >= math.MinInt64 && number < math.MaxInt64 {
+				node.Tag = "!!int"
+			}
+			return node
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(number, 'f', -1, 64)}
+	case *structpb.Value_StringValue:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: kind.StringValue}
+	case *structpb.Value_ListValue:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range kind.ListValue.GetValues() {
+			node.Content = append(node.Content, convertValueToYAML(item))
+		}
+		return node
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				convertValueToYAML(fields[key]),
+			)
+		}
+		return node
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+}
@@ -2,9 +2,8 @@ package generator
 
 import (
 	"fmt"
-	"io"
 	"log"
-	"os"
+	"path"
 
 	high "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"google.golang.org/protobuf/compiler/protogen"
@@ -18,19 +17,48 @@ const (
 	FormatYAML OutputFormat = "yaml"
 )
 
+// OpenAPIVersion represents the OpenAPI specification version of the output
+type OpenAPIVersion string
+
+const (
+	OpenAPIVersion30 OpenAPIVersion = "3.0"
+	OpenAPIVersion31 OpenAPIVersion = "3.1"
+)
+
 // Options contains all the configuration options for the OpenAPI generator
 type Options struct {
 	AllowMerge           bool
 	IncludePackageInTags bool
 	FQNForOpenAPIName    bool
-	OutputFile           string       // Path to output file, empty means stdout
-	OutputFormat         OutputFormat // Format of the output file (json or yaml)
+	OutputFile           string          // Path of the merged output file, relative to the output directory
+	OutputFormat         OutputFormat    // Format of the output file (json or yaml)
+	JSONSchemaDialect    string          // Default jsonSchemaDialect URI, overridden by the file option
+	OpenAPIVersion       OpenAPIVersion  // OpenAPI version of the output (3.0 or 3.1)
+	NamingStrategy       NamingStrategy  // Naming of schema components, defaults to simple or fqn with FQNForOpenAPIName
+	OperationIDTemplate  string          // Template of generated operationIds, defaults to {Method}
+	OperationIDCase      OperationIDCase // Letter case applied to generated operationIds
+	DisableDefaultErrors bool            // Do not add a default error response to operations
+	DefaultErrorType     string          // Fully qualified message of the default error response, defaults to google.rpc.Status
+	Migrate              bool            // Write the v3 annotations equivalent to the v2 annotations instead of the specifications
 }
 
 // OpenAPIGenerator handles the generation of OpenAPI specifications
 type OpenAPIGenerator struct {
 	gen     *protogen.Plugin
 	options *Options
+
+	// merged accumulates the documents of all proto files when AllowMerge is set
+	merged *high.Document
+	// mergedInfo records whether the merged document info comes from an annotation
+	mergedInfo bool
+	// mergedFiles holds the parsed files of the merged document, to check references across them
+	mergedFiles []*ParsedFile
+	// schemaNames holds the component name of every type of the files to generate
+	schemaNames map[string]string
+	// prescanned holds the files parsed while resolving the component names, until they are generated
+	prescanned map[*protogen.File]*ParsedFile
+	// callbackTargets holds the RPCs referenced by the callbacks of any input file, by fully qualified name
+	callbackTargets map[string]bool
 }
 
 // NewOpenAPIGenerator creates a new OpenAPI generator with the given options
@@ -38,6 +66,9 @@ func NewOpenAPIGenerator(gen *protogen.Plugin, options *Options) *OpenAPIGenerat
 	if options.OutputFormat == "" {
 		options.OutputFormat = FormatYAML
 	}
+	if options.OpenAPIVersion == "" {
+		options.OpenAPIVersion = OpenAPIVersion31
+	}
 
 	return &OpenAPIGenerator{
 		gen:     gen,
@@ -47,6 +78,10 @@ func NewOpenAPIGenerator(gen *protogen.Plugin, options *Options) *OpenAPIGenerat
 
 // Generate processes a single proto file and generates its OpenAPI specification
 func (g *OpenAPIGenerator) Generate(file *protogen.File) error {
+	if g.options.Migrate {
+		return g.migrate(file)
+	}
+
 	// Parse the proto file
 	parsedFile, err := g.ParseProtoFile(file)
 	if err != nil {
@@ -65,7 +100,7 @@ func (g *OpenAPIGenerator) Generate(file *protogen.File) error {
 	// 3. Processing HTTP annotations from parsedFile.Annotations
 
 	// 4. Generating OpenAPI paths and components using parsedFile.Messages and parsedFile.Enums
-	oapiDoc, err := ConvertToOpenAPI(parsedFile)
+	oapiDoc, err := ConvertToOpenAPI(parsedFile, g.options)
 	if err != nil {
 		return fmt.Errorf("failed to convert to OpenAPI: %w", err)
 	}
@@ -77,40 +112,81 @@ func (g *OpenAPIGenerator) Generate(file *protogen.File) error {
 		oapiDoc.Paths.PathItems,
 		oapiDoc.Components.Schemas)
 
-	// TODO: Implement remaining steps using parsedFile
-	// 5. Writing the output file
+	// Accumulate the document, it is written once all files are processed
+	if g.options.AllowMerge {
+		return g.merge(parsedFile, oapiDoc)
+	}
+
+	// Write the output next to the proto file
+	if err := g.writeOutput(file.GeneratedFilenamePrefix+".openapi."+g.extension(), oapiDoc); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// merge accumulates the OpenAPI document of a proto file into the merged document
+func (g *OpenAPIGenerator) merge(parsedFile *ParsedFile, doc *high.Document) error {
+	g.mergedFiles = append(g.mergedFiles, parsedFile)
+
+	if g.merged == nil {
+		g.merged = doc
+		g.mergedInfo = parsedFile.Info != nil
+		return nil
+	}
+
+	// The first annotated info wins over the info defaulted from the package name
+	if parsedFile.Info != nil && !g.mergedInfo {
+		g.merged.Info = doc.Info
+		g.mergedInfo = true
+	}
+
+	if err := mergeDocuments(g.merged, doc); err != nil {
+		return fmt.Errorf("failed to merge OpenAPI spec: %w", err)
+	}
+
+	return nil
+}
+
+// Finish writes the merged OpenAPI document once every proto file has been processed.
+// It does nothing unless AllowMerge is set.
+func (g *OpenAPIGenerator) Finish() error {
+	if !g.options.AllowMerge || g.merged == nil {
+		return nil
+	}
 
-	// Write the output
-	if err := g.writeOutput(oapiDoc); err != nil {
+	if err := validateMergedComponentRefs(g.mergedFiles); err != nil {
+		return err
+	}
+	if err := validateMergedSecurity(g.mergedFiles); err != nil {
+		return err
+	}
+
+	filename := g.options.OutputFile
+	if filename == "" {
+		filename = "openapi." + g.extension()
+	}
+
+	if err := g.writeOutput(path.Clean(filename), g.merged); err != nil {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
 
 	return nil
 }
 
-// writeOutput writes the OpenAPI document to either stdout or a file
-func (g *OpenAPIGenerator) writeOutput(doc *high.Document) error {
-	var writer io.Writer
-
-	if g.options.OutputFile == "" {
-		// Write to stdout
-		writer = os.Stdout
-	} else {
-		/*
-			// Create output directory if it doesn't exist
-			outputDir := filepath.Dir(g.options.OutputFile)
-			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				return fmt.Errorf("failed to create output directory: %w", err)
-			}
-		*/
-
-		// Create or truncate the output file
-		f, err := os.Create(g.options.OutputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
-		}
-		defer f.Close()
-		writer = f
+// extension returns the file extension matching the output format
+func (g *OpenAPIGenerator) extension() string {
+	if g.options.OutputFormat == FormatJSON {
+		return "json"
+	}
+	return "yaml"
+}
+
+// writeOutput renders the OpenAPI document into a file of the plugin response
+func (g *OpenAPIGenerator) writeOutput(filename string, doc *high.Document) error {
+	// OpenAPI 3.0 documents webhooks under an extension
+	if err := webhooksExtension(doc); err != nil {
+		return err
 	}
 
 	// Render the document based on format
@@ -130,8 +206,8 @@ func (g *OpenAPIGenerator) writeOutput(doc *high.Document) error {
 		return fmt.Errorf("failed to render OpenAPI document: %w", err)
 	}
 
-	// Write the rendered output
-	if _, err := writer.Write(data); err != nil {
+	// Write the rendered output, protoc takes care of creating the file
+	if _, err := g.gen.NewGeneratedFile(filename, "").Write(data); err != nil {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
 
@@ -0,0 +1,911 @@
+package generator_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v2options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/sapk/protoc-gen-openapiv3/generator"
+	"github.com/sapk/protoc-gen-openapiv3/options"
+)
+
+// testFile builds a proto file with a single message and a service exposing one GET method
+func testFile(name, pkg, service, message, path string) *descriptorpb.FileDescriptorProto {
+	methodOptions := &descriptorpb.MethodOptions{}
+	proto.SetExtension(methodOptions, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: path},
+	})
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String(name),
+		Package: proto.String(pkg),
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{
+			GoPackage: proto.String("example.com/" + pkg),
+		},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String(message),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("id"),
+						JsonName: proto.String("id"),
+						Number:   proto.Int32(1),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String(service),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("Get" + message),
+						InputType:  proto.String("." + pkg + "." + message),
+						OutputType: proto.String("." + pkg + "." + message),
+						Options:    methodOptions,
+					},
+				},
+			},
+		},
+	}
+}
+
+// newTestPlugin creates a protogen plugin generating all the given files
+func newTestPlugin(t *testing.T, parameter string, files ...*descriptorpb.FileDescriptorProto) *protogen.Plugin {
+	t.Helper()
+
+	req := &pluginpb.CodeGeneratorRequest{ProtoFile: files, Parameter: proto.String(parameter)}
+	for _, file := range files {
+		req.FileToGenerate = append(req.FileToGenerate, file.GetName())
+	}
+
+	gen, err := protogen.Options{}.New(req)
+	require.NoError(t, err)
+	return gen
+}
+
+// responseFiles returns the content of the files generated by the plugin, by name
+func responseFiles(t *testing.T, gen *protogen.Plugin) map[string]string {
+	t.Helper()
+
+	resp := gen.Response()
+	require.Empty(t, resp.GetError())
+
+	files := make(map[string]string)
+	for _, file := range resp.GetFile() {
+		files[file.GetName()] = file.GetContent()
+	}
+	return files
+}
+
+func TestGenerate_PerFileOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		parameter string
+		format    generator.OutputFormat
+		expected  []string
+	}{
+		{
+			name:     "import paths",
+			format:   generator.FormatYAML,
+			expected: []string{"example.com/a.v1/user.openapi.yaml", "example.com/b.v1/group.openapi.yaml"},
+		},
+		{
+			name:      "source relative paths",
+			parameter: "paths=source_relative",
+			format:    generator.FormatYAML,
+			expected:  []string{"a/v1/user.openapi.yaml", "b/v1/group.openapi.yaml"},
+		},
+		{
+			name:      "json format",
+			parameter: "paths=source_relative",
+			format:    generator.FormatJSON,
+			expected:  []string{"a/v1/user.openapi.json", "b/v1/group.openapi.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := newTestPlugin(t, tt.parameter,
+				testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users"),
+				testFile("b/v1/group.proto", "b.v1", "GroupService", "Group", "/v1/groups"),
+			)
+			oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: tt.format})
+
+			for _, f := range gen.Files {
+				require.NoError(t, oapiGenerator.Generate(f))
+			}
+			require.NoError(t, oapiGenerator.Finish())
+
+			files := responseFiles(t, gen)
+			require.Len(t, files, len(tt.expected))
+			for _, name := range tt.expected {
+				assert.Contains(t, files, name)
+			}
+			assert.Contains(t, files[tt.expected[0]], "/v1/users")
+			assert.NotContains(t, files[tt.expected[0]], "/v1/groups")
+		})
+	}
+}
+
+func TestGenerate_AllowMerge(t *testing.T) {
+	gen := newTestPlugin(t, "",
+		testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users"),
+		testFile("b/v1/group.proto", "b.v1", "GroupService", "Group", "/v1/groups"),
+	)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{
+		AllowMerge: true,
+		OutputFile: "./api/openapi.yaml",
+	})
+
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+
+	// Nothing is written until every file has been processed
+	assert.Empty(t, responseFiles(t, gen))
+
+	require.NoError(t, oapiGenerator.Finish())
+	files := responseFiles(t, gen)
+	require.Len(t, files, 1)
+	data := files["api/openapi.yaml"]
+	assert.Contains(t, data, "/v1/users:")
+	assert.Contains(t, data, "/v1/groups:")
+	assert.Contains(t, data, "User:")
+	assert.Contains(t, data, "Group:")
+}
+
+func TestGenerate_AllowMergeConflict(t *testing.T) {
+	gen := newTestPlugin(t, "",
+		testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users"),
+		testFile("b/v1/user.proto", "b.v1", "AdminService", "User", "/v1/users"),
+	)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{
+		AllowMerge: true,
+	})
+
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+	err := oapiGenerator.Generate(gen.Files[1])
+	assert.ErrorContains(t, err, "conflicting definitions for path GET /v1/users")
+}
+
+func TestGenerate_AllowMergeSameMessageName(t *testing.T) {
+	gen := newTestPlugin(t, "",
+		testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users"),
+		testFile("b/v1/user.proto", "b.v1", "AdminService", "User", "/v1/admin/users"),
+	)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{
+		AllowMerge:          true,
+		OperationIDTemplate: "{Service}_{Method}",
+	})
+
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+	require.NoError(t, oapiGenerator.Finish())
+
+	// Colliding names fall back to fully qualified component names
+	data := responseFiles(t, gen)["openapi.yaml"]
+	assert.Contains(t, data, "$ref: '#/components/schemas/a.v1.User'")
+	assert.Contains(t, data, "$ref: '#/components/schemas/b.v1.User'")
+	assert.NotContains(t, data, "#/components/schemas/User'")
+}
+
+func TestGenerate_AllowMergeDuplicateOperationID(t *testing.T) {
+	gen := newTestPlugin(t, "",
+		testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users"),
+		testFile("b/v1/user.proto", "b.v1", "AdminService", "User", "/v1/admin/users"),
+	)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{AllowMerge: true})
+
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+	err := oapiGenerator.Generate(gen.Files[1])
+	assert.ErrorContains(t, err, `conflicting definitions for operationId "GetUser"`)
+}
+
+func TestGenerate_AllowMergeSharedComponents(t *testing.T) {
+	common := testFile("common/v1/common.proto", "common.v1", "HealthService", "Health", "/v1/health")
+	proto.SetExtension(common.GetOptions(), options.E_Components, &options.Components{
+		Responses: map[string]*options.Response{"NotFound": {Description: "Resource not found"}},
+	})
+	user := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(user.GetService()[0].GetMethod()[0].GetOptions(), options.E_Operation, &options.Operation{
+		Responses: []*options.Response{{Code: "404", Ref: "NotFound"}},
+	})
+
+	// The service file may be merged before the file declaring the component
+	gen := newTestPlugin(t, "", user, common)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{AllowMerge: true})
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+	require.NoError(t, oapiGenerator.Finish())
+
+	data := responseFiles(t, gen)["openapi.yaml"]
+	assert.Contains(t, data, "$ref: '#/components/responses/NotFound'")
+	assert.Contains(t, data, "description: Resource not found")
+
+	// Names declared by none of the merged files are still reported
+	missing := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(missing.GetService()[0].GetMethod()[0].GetOptions(), options.E_Operation, &options.Operation{
+		Responses: []*options.Response{{Code: "404", Ref: "Gone"}},
+	})
+	gen = newTestPlugin(t, "", missing, common)
+	oapiGenerator = generator.NewOpenAPIGenerator(gen, &generator.Options{AllowMerge: true})
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+	assert.ErrorContains(t, oapiGenerator.Finish(), `unknown responses component "Gone"`)
+}
+
+func TestGenerate_CrossFileCallback(t *testing.T) {
+	// The callback target has no HTTP rule and lives in another file
+	events := testFile("b/v1/events.proto", "b.v1", "EventService", "Event", "/v1/events")
+	events.GetService()[0].GetMethod()[0].Options = nil
+	user := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(user.GetService()[0].GetMethod()[0].GetOptions(), options.E_Callback, []*options.Callback{{
+		Name:       "userEvent",
+		Expression: "{$request.query.callback_url}",
+		Rpc:        "b.v1.EventService.GetEvent",
+	}})
+
+	gen := newTestPlugin(t, "paths=source_relative", events, user)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+
+	files := responseFiles(t, gen)
+	assert.Contains(t, files["a/v1/user.openapi.yaml"], `callbacks:
+                userEvent:
+                    '{$request.query.callback_url}':
+                        post:
+                            tags:
+                                - EventService
+                            operationId: GetEvent`)
+	// The target is only documented as a callback, not at a made-up path of its own file
+	assert.NotContains(t, files["b/v1/events.openapi.yaml"], "/get-event")
+}
+
+func TestGenerate_OpenAPI30Webhooks(t *testing.T) {
+	user := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	events := testFile("a/v1/events.proto", "a.v1", "EventService", "Event", "/v1/events")
+	for _, file := range []*descriptorpb.FileDescriptorProto{user, events} {
+		method := file.GetService()[0].GetMethod()[0]
+		method.Options = &descriptorpb.MethodOptions{}
+		proto.SetExtension(method.GetOptions(), options.E_Webhook, &options.Webhook{})
+	}
+
+	gen := newTestPlugin(t, "", user, events)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{
+		OpenAPIVersion: generator.OpenAPIVersion30,
+		AllowMerge:     true,
+		OutputFile:     "openapi.yaml",
+	})
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+	require.NoError(t, oapiGenerator.Finish())
+
+	// OpenAPI 3.0 has no webhooks object, the webhooks of every merged file are documented as an extension
+	files := responseFiles(t, gen)
+	merged := files["openapi.yaml"]
+	assert.NotContains(t, merged, "\nwebhooks:")
+	assert.Contains(t, merged, `x-webhooks:
+    GetUser:
+        post:
+            tags:
+                - UserService
+            operationId: GetUser`)
+	assert.Contains(t, merged, `    GetEvent:
+        post:
+            tags:
+                - EventService
+            operationId: GetEvent`)
+}
+
+func TestGenerate_AllowMergeSharedSecuritySchemes(t *testing.T) {
+	common := testFile("common/v1/common.proto", "common.v1", "HealthService", "Health", "/v1/health")
+	proto.SetExtension(common.GetOptions(), v2options.E_Openapiv2Swagger, &v2options.Swagger{
+		SecurityDefinitions: &v2options.SecurityDefinitions{
+			Security: map[string]*v2options.SecurityScheme{
+				"bearerAuth": {Type: v2options.SecurityScheme_TYPE_API_KEY, Name: "Authorization", In: v2options.SecurityScheme_IN_HEADER},
+			},
+		},
+	})
+	user := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(user.GetOptions(), options.E_Security, []*options.SecurityRequirement{{Name: "bearerAuth"}})
+
+	gen := newTestPlugin(t, "", user, common)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{AllowMerge: true})
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+	require.NoError(t, oapiGenerator.Finish())
+
+	data := responseFiles(t, gen)["openapi.yaml"]
+	assert.Contains(t, data, "- bearerAuth: []")
+	assert.Contains(t, data, "name: Authorization")
+
+	// Schemes declared by none of the merged files are still reported
+	missing := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(missing.GetOptions(), options.E_Security, []*options.SecurityRequirement{{Name: "basicAuth"}})
+	gen = newTestPlugin(t, "", missing, common)
+	oapiGenerator = generator.NewOpenAPIGenerator(gen, &generator.Options{AllowMerge: true})
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+	assert.ErrorContains(t, oapiGenerator.Finish(), `references unknown security scheme "basicAuth"`)
+}
+
+func TestGenerate_DefaultErrorTypeFromOtherFile(t *testing.T) {
+	gen := newTestPlugin(t, "paths=source_relative",
+		testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users"),
+		testFile("errors/v1/problem.proto", "errors.v1", "ProblemService", "Problem", "/v1/problems"),
+	)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{
+		OutputFormat:     generator.FormatYAML,
+		DefaultErrorType: "errors.v1.Problem",
+	})
+
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+	data := responseFiles(t, gen)["a/v1/user.openapi.yaml"]
+	assert.Contains(t, data, "description: An unexpected error response.")
+	assert.Contains(t, data, "$ref: '#/components/schemas/Problem'")
+	assert.Contains(t, data, "Problem:\n")
+	assert.NotContains(t, data, "Status:")
+
+	oapiGenerator = generator.NewOpenAPIGenerator(gen, &generator.Options{DefaultErrorType: "errors.v1.Missing"})
+	err := oapiGenerator.Generate(gen.Files[0])
+	assert.ErrorContains(t, err, `default error type "errors.v1.Missing" not found`)
+}
+
+func TestGenerate_UserStatusMessage(t *testing.T) {
+	// Every operation documents its own default response, so google.rpc.Status is not rendered
+	file := testFile("a/v1/status.proto", "a.v1", "StatusService", "Status", "/v1/status")
+	proto.SetExtension(file.GetService()[0].GetMethod()[0].GetOptions(), options.E_Operation, &options.Operation{
+		Responses: []*options.Response{{Code: "200", Description: "The status"}, {Code: "default", Description: "An error"}},
+	})
+	gen := newTestPlugin(t, "paths=source_relative", file)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+
+	// The user message keeps its name instead of being disambiguated from the unused error model
+	data := responseFiles(t, gen)["a/v1/status.openapi.yaml"]
+	assert.Contains(t, data, "$ref: '#/components/schemas/Status'")
+	assert.Contains(t, data, "        Status:\n")
+	assert.NotContains(t, data, "a.v1.Status")
+	assert.NotContains(t, data, "google.rpc.Status")
+
+	// Both messages are disambiguated when the generated default response references google.rpc.Status
+	gen = newTestPlugin(t, "paths=source_relative", testFile("a/v1/status.proto", "a.v1", "StatusService", "Status", "/v1/status"))
+	oapiGenerator = generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+
+	data = responseFiles(t, gen)["a/v1/status.openapi.yaml"]
+	assert.Contains(t, data, "$ref: '#/components/schemas/a.v1.Status'")
+	assert.Contains(t, data, "$ref: '#/components/schemas/google.rpc.Status'")
+}
+
+func TestGenerate_ImportedTypes(t *testing.T) {
+	messageField := func(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(typeName),
+		}
+	}
+
+	address := testFile("b/v1/address.proto", "b.v1", "AddressService", "Address", "/v1/addresses")
+	address.EnumType = []*descriptorpb.EnumDescriptorProto{{
+		Name: proto.String("Kind"),
+		Value: []*descriptorpb.EnumValueDescriptorProto{
+			{Name: proto.String("KIND_UNSPECIFIED"), Number: proto.Int32(0)},
+			{Name: proto.String("KIND_HOME"), Number: proto.Int32(1)},
+		},
+	}}
+	kind := messageField("kind", 2, ".b.v1.Kind")
+	kind.Type = descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
+	address.MessageType[0].Field = append(address.MessageType[0].Field, kind)
+
+	user := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	user.Dependency = []string{"b/v1/address.proto", "google/protobuf/wrappers.proto", "google/protobuf/field_mask.proto"}
+	user.MessageType[0].Field = append(user.MessageType[0].Field,
+		messageField("address", 2, ".b.v1.Address"),
+		messageField("nickname", 3, ".google.protobuf.StringValue"),
+		messageField("update_mask", 4, ".google.protobuf.FieldMask"),
+	)
+
+	gen := newTestPlugin(t, "paths=source_relative",
+		protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+		protodesc.ToFileDescriptorProto(fieldmaskpb.File_google_protobuf_field_mask_proto),
+		address, user,
+	)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	require.NoError(t, oapiGenerator.Generate(gen.FilesByPath["a/v1/user.proto"]))
+
+	// The imported message and the enum it references are rendered as components
+	data := responseFiles(t, gen)["a/v1/user.openapi.yaml"]
+	assert.Contains(t, data, `                address:
+                    $ref: '#/components/schemas/Address'`)
+	assert.Contains(t, data, `        Address:
+            type: object
+            properties:
+                id:
+                    type: string
+                kind:
+                    $ref: '#/components/schemas/Kind'`)
+	assert.Contains(t, data, `        Kind:
+            type: string
+            enum:
+                - KIND_UNSPECIFIED
+                - KIND_HOME`)
+
+	// Well-known types use their JSON mapping
+	assert.Contains(t, data, `                nickname:
+                    type:
+                        - string
+                        - "null"`)
+	assert.Contains(t, data, `                update_mask:
+                    type: string`)
+	assert.NotContains(t, data, "StringValue")
+	assert.NotContains(t, data, "FieldMask")
+}
+
+func TestGenerate_OperationAnnotation(t *testing.T) {
+	file := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(file.GetService()[0].GetMethod()[0].GetOptions(), options.E_Operation, &options.Operation{
+		Summary:   "Get a user",
+		Responses: []*options.Response{{Code: "404", Ref: "NotFound"}},
+	})
+	proto.SetExtension(file.GetOptions(), options.E_Components, &options.Components{
+		Responses: map[string]*options.Response{"NotFound": {Description: "User not found"}},
+	})
+
+	gen := newTestPlugin(t, "paths=source_relative", file)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+
+	// The v3 annotation is kept when the method has no v2 annotation
+	data := responseFiles(t, gen)["a/v1/user.openapi.yaml"]
+	assert.Contains(t, data, "summary: Get a user")
+	assert.Contains(t, data, "$ref: '#/components/responses/NotFound'")
+	assert.Contains(t, data, "description: User not found")
+}
+
+func TestGenerate_V2SecuritySchemes(t *testing.T) {
+	file := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(file.GetOptions(), v2options.E_Openapiv2Swagger, &v2options.Swagger{
+		SecurityDefinitions: &v2options.SecurityDefinitions{
+			Security: map[string]*v2options.SecurityScheme{
+				"implicit": {
+					Type:             v2options.SecurityScheme_TYPE_OAUTH2,
+					Flow:             v2options.SecurityScheme_FLOW_IMPLICIT,
+					AuthorizationUrl: "https://auth.test.com/authorize",
+					Scopes:           &v2options.Scopes{Scope: map[string]string{"read": "Read access"}},
+				},
+				"password": {
+					Type:     v2options.SecurityScheme_TYPE_OAUTH2,
+					Flow:     v2options.SecurityScheme_FLOW_PASSWORD,
+					TokenUrl: "https://auth.test.com/password",
+				},
+				"application": {
+					Type:     v2options.SecurityScheme_TYPE_OAUTH2,
+					Flow:     v2options.SecurityScheme_FLOW_APPLICATION,
+					TokenUrl: "https://auth.test.com/application",
+				},
+				"queryKey": {
+					Type: v2options.SecurityScheme_TYPE_API_KEY,
+					Name: "api_key",
+					In:   v2options.SecurityScheme_IN_QUERY,
+				},
+			},
+		},
+	})
+
+	gen := newTestPlugin(t, "paths=source_relative", file)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+
+	data := responseFiles(t, gen)["a/v1/user.openapi.yaml"]
+	assert.Contains(t, data, `        implicit:
+            type: oauth2
+            flows:
+                implicit:
+                    authorizationUrl: https://auth.test.com/authorize
+                    scopes:
+                        read: Read access`)
+	assert.Contains(t, data, `        password:
+            type: oauth2
+            flows:
+                password:
+                    tokenUrl: https://auth.test.com/password
+                    scopes: {}`)
+	assert.Contains(t, data, `        application:
+            type: oauth2
+            flows:
+                clientCredentials:
+                    tokenUrl: https://auth.test.com/application
+                    scopes: {}`)
+	assert.Contains(t, data, `        queryKey:
+            type: apiKey
+            name: api_key
+            in: query`)
+}
+
+func TestGenerate_Golden(t *testing.T) {
+	// a_bit_of_everything.proto is vendored from grpc-gateway to cover its real-world v2 annotations
+	for _, name := range []string{"test", "test.v2", "schema.v2", "examples/internal/proto/examplepb/a_bit_of_everything"} {
+		t.Run(name, func(t *testing.T) {
+			// Build the descriptors the way testdata/generate.sh invokes the plugin
+			pbFile := filepath.Join(t.TempDir(), "descriptor.pb")
+			cmd := exec.Command("protoc",
+				"--descriptor_set_out="+pbFile,
+				"--include_imports",
+				"--include_source_info",
+				"--proto_path=.",
+				name+".proto")
+			cmd.Dir = "../testdata"
+			output, err := cmd.CombinedOutput()
+			require.NoError(t, err, "protoc failed: %s", output)
+
+			data, err := os.ReadFile(pbFile)
+			require.NoError(t, err)
+			fdSet := &descriptorpb.FileDescriptorSet{}
+			require.NoError(t, proto.Unmarshal(data, fdSet))
+
+			gen := newTestPlugin(t, "paths=source_relative", fdSet.GetFile()...)
+			for _, file := range gen.Files {
+				file.Generate = file.Desc.Path() == name+".proto"
+			}
+			oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+			for _, file := range gen.Files {
+				if file.Generate {
+					require.NoError(t, oapiGenerator.Generate(file))
+				}
+			}
+
+			golden, err := os.ReadFile(filepath.Join("../testdata", name+".openapi.yaml"))
+			require.NoError(t, err)
+			assert.YAMLEq(t, string(golden), responseFiles(t, gen)[name+".openapi.yaml"],
+				"regenerate the golden file with testdata/generate.sh")
+
+			// The golden must be a valid document whose references all resolve
+			document, err := libopenapi.NewDocument(golden)
+			require.NoError(t, err)
+			_, errs := document.BuildV3Model()
+			assert.Empty(t, errs, "invalid OpenAPI document")
+		})
+	}
+}
+
+func TestGenerate_V2Operation(t *testing.T) {
+	file := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(file.GetOptions(), v2options.E_Openapiv2Swagger, &v2options.Swagger{
+		Host:     "api.test.com",
+		BasePath: "/v1",
+		SecurityDefinitions: &v2options.SecurityDefinitions{
+			Security: map[string]*v2options.SecurityScheme{
+				"basic":  {Type: v2options.SecurityScheme_TYPE_BASIC},
+				"apiKey": {Type: v2options.SecurityScheme_TYPE_API_KEY, Name: "X-API-Key", In: v2options.SecurityScheme_IN_HEADER},
+			},
+		},
+	})
+	proto.SetExtension(file.GetService()[0].GetMethod()[0].GetOptions(), v2options.E_Openapiv2Operation, &v2options.Operation{
+		OperationId:  "fetchUser",
+		ExternalDocs: &v2options.ExternalDocumentation{Url: "https://test.com/docs/users"},
+		Schemes:      []v2options.Scheme{v2options.Scheme_WSS, v2options.Scheme_HTTP},
+		Security: []*v2options.SecurityRequirement{{
+			SecurityRequirement: map[string]*v2options.SecurityRequirement_SecurityRequirementValue{
+				"basic":  {},
+				"apiKey": {},
+			},
+		}},
+		Responses: map[string]*v2options.Response{
+			"200": {
+				Description: "The user",
+				Headers:     map[string]*v2options.Header{"ETag": {Type: "string"}},
+				Examples:    map[string]string{"application/json": `{"id": "1"}`},
+			},
+		},
+	})
+
+	gen := newTestPlugin(t, "paths=source_relative", file)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+
+	data := responseFiles(t, gen)["a/v1/user.openapi.yaml"]
+	assert.Contains(t, data, "operationId: fetchUser")
+	assert.Contains(t, data, "url: https://test.com/docs/users")
+	// Each scheme of the operation becomes a server
+	assert.Contains(t, data, `servers:
+                - url: wss://api.test.com/v1
+                  description: Server for api.test.com
+                - url: http://api.test.com/v1
+                  description: Server for api.test.com`)
+	// Both schemes of the requirement are required together
+	assert.Contains(t, data, `security:
+                - apiKey: []
+                  basic: []`)
+	assert.Contains(t, data, `ETag:
+                            schema:
+                                type: string`)
+	assert.Contains(t, data, `example: {"id": "1"}`)
+}
+
+func TestGenerate_V2ServersWithoutHost(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	file := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(file.GetOptions(), v2options.E_Openapiv2Swagger, &v2options.Swagger{
+		BasePath: "/api",
+		Schemes:  []v2options.Scheme{v2options.Scheme_HTTPS, v2options.Scheme_WSS},
+	})
+	proto.SetExtension(file.GetService()[0].GetMethod()[0].GetOptions(), v2options.E_Openapiv2Operation, &v2options.Operation{
+		Schemes: []v2options.Scheme{v2options.Scheme_WSS},
+	})
+
+	gen := newTestPlugin(t, "paths=source_relative", file)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+
+	// Without a host the servers are the base path, relative to the document
+	data := responseFiles(t, gen)["a/v1/user.openapi.yaml"]
+	assert.Contains(t, data, `servers:
+    - url: /api`)
+	assert.Contains(t, data, `servers:
+                - url: /api`)
+	assert.Contains(t, logs.String(), "warning: package a.v1 sets schemes without a host")
+	assert.Contains(t, logs.String(), "warning: method GetUser sets schemes without a host")
+}
+
+func TestGenerate_V2V3Merge(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	file := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(file.GetOptions(), options.E_Info, &options.Info{
+		Title:      "Users API",
+		Extensions: map[string]*structpb.Value{"x-audience": structpb.NewStringValue("internal")},
+	})
+	proto.SetExtension(file.GetOptions(), options.E_Extensions, &structpb.Struct{
+		Fields: map[string]*structpb.Value{"x-owner": structpb.NewStringValue("users-team")},
+	})
+	proto.SetExtension(file.GetOptions(), options.E_SecurityScheme, []*options.SecurityScheme{{
+		Name:       "ApiKeyAuth",
+		Type:       "apiKey",
+		In:         "header",
+		Extensions: map[string]*structpb.Value{"x-key-rotation": structpb.NewStringValue("monthly")},
+	}})
+	proto.SetExtension(file.GetOptions(), v2options.E_Openapiv2Swagger, &v2options.Swagger{
+		Info: &v2options.Info{
+			Title:   "Legacy API",
+			Version: "2.0.0",
+			Extensions: map[string]*structpb.Value{
+				"x-audience": structpb.NewStringValue("public"),
+				"x-logo":     structpb.NewStringValue("logo.png"),
+			},
+		},
+		Extensions: map[string]*structpb.Value{
+			"x-owner":  structpb.NewStringValue("legacy-team"),
+			"x-region": structpb.NewStringValue("eu"),
+		},
+		SecurityDefinitions: &v2options.SecurityDefinitions{
+			Security: map[string]*v2options.SecurityScheme{
+				"ApiKeyAuth": {
+					Type:       v2options.SecurityScheme_TYPE_API_KEY,
+					Name:       "X-API-Key",
+					In:         v2options.SecurityScheme_IN_HEADER,
+					Extensions: map[string]*structpb.Value{"x-amazon-apigateway-authtype": structpb.NewStringValue("custom")},
+				},
+			},
+		},
+	})
+
+	file.GetService()[0].Options = &descriptorpb.ServiceOptions{}
+	proto.SetExtension(file.GetService()[0].GetOptions(), options.E_ServiceTag, &options.Tag{Name: "Users"})
+	proto.SetExtension(file.GetService()[0].GetOptions(), v2options.E_Openapiv2Tag, &v2options.Tag{
+		Name:        "Users",
+		Description: "Manage the users",
+	})
+
+	methodOptions := file.GetService()[0].GetMethod()[0].GetOptions()
+	proto.SetExtension(methodOptions, options.E_Operation, &options.Operation{
+		Summary: "Get a user",
+		Responses: []*options.Response{{
+			Code:        "200",
+			Description: "The user",
+			Content: map[string]*options.MediaType{
+				"application/json": {Schema: &options.Schema{Ref: "#/components/schemas/User"}},
+			},
+		}},
+	})
+	proto.SetExtension(methodOptions, v2options.E_Openapiv2Operation, &v2options.Operation{
+		Summary:     "Fetch a user",
+		Description: "Returns the user by id",
+		Responses: map[string]*v2options.Response{
+			"200": {Description: "A user"},
+			"404": {Description: "Not found"},
+		},
+	})
+
+	file.GetMessageType()[0].Options = &descriptorpb.MessageOptions{}
+	proto.SetExtension(file.GetMessageType()[0].GetOptions(), options.E_Schema, &options.Schema{Title: "User"})
+	proto.SetExtension(file.GetMessageType()[0].GetOptions(), v2options.E_Openapiv2Schema, &v2options.Schema{
+		JsonSchema: &v2options.JSONSchema{Description: "A user of the API"},
+	})
+
+	file.GetMessageType()[0].GetField()[0].Options = &descriptorpb.FieldOptions{}
+	proto.SetExtension(file.GetMessageType()[0].GetField()[0].GetOptions(), options.E_Field, &options.Schema{MaxLength: 36})
+	proto.SetExtension(file.GetMessageType()[0].GetField()[0].GetOptions(), v2options.E_Openapiv2Field, &v2options.JSONSchema{
+		MaxLength: 64,
+		Pattern:   "^[a-z0-9-]+$",
+	})
+
+	gen := newTestPlugin(t, "paths=source_relative", file)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{OutputFormat: generator.FormatYAML})
+	require.NoError(t, oapiGenerator.Generate(gen.Files[0]))
+
+	data := responseFiles(t, gen)["a/v1/user.openapi.yaml"]
+	// v3 values win, v2 values fill the gaps
+	assert.Contains(t, data, "title: Users API")
+	assert.Contains(t, data, "version: 2.0.0")
+	assert.Contains(t, data, "description: Manage the users")
+	assert.Contains(t, data, "summary: Get a user")
+	assert.Contains(t, data, "description: Returns the user by id")
+	assert.Contains(t, data, "description: The user")
+	assert.Contains(t, data, "description: Not found")
+	assert.Contains(t, data, "title: User")
+	assert.Contains(t, data, "description: A user of the API")
+	assert.Contains(t, data, "maxLength: 36")
+	assert.Contains(t, data, "pattern: ^[a-z0-9-]+$")
+	assert.NotContains(t, data, "Legacy API")
+	assert.NotContains(t, data, "Fetch a user")
+	assert.NotContains(t, data, "maxLength: 64")
+
+	// Extensions are merged by name, on the document, its info and security schemes
+	assert.Contains(t, data, "x-audience: internal")
+	assert.Contains(t, data, "x-logo: logo.png")
+	assert.Contains(t, data, "x-owner: users-team")
+	assert.Contains(t, data, "x-region: eu")
+	assert.Contains(t, data, "x-key-rotation: monthly")
+	assert.Contains(t, data, "x-amazon-apigateway-authtype: custom")
+	assert.NotContains(t, data, "public")
+	assert.NotContains(t, data, "legacy-team")
+
+	// Conflicting values are reported
+	assert.Contains(t, logs.String(), "warning: info of package a.v1 sets title in both its v2 and v3 annotations")
+	assert.Contains(t, logs.String(), "warning: info of package a.v1 sets extensions.x-audience in both its v2 and v3 annotations")
+	assert.Contains(t, logs.String(), "warning: extensions of package a.v1 sets x-owner in both its v2 and v3 annotations")
+	assert.Equal(t, 1, strings.Count(logs.String(), "sets summary in both its v2 and v3 annotations"))
+	assert.Contains(t, logs.String(), "sets description in both its v2 and v3 annotations")
+	assert.Contains(t, logs.String(), "sets max_length in both its v2 and v3 annotations")
+}
+
+func TestGenerate_Migrate(t *testing.T) {
+	file := testFile("a/v1/user.proto", "a.v1", "UserService", "User", "/v1/users")
+	proto.SetExtension(file.GetOptions(), v2options.E_Openapiv2Swagger, &v2options.Swagger{
+		Info: &v2options.Info{
+			Title:      "Users API",
+			Version:    "1.0.0",
+			Extensions: map[string]*structpb.Value{"x-logo": structpb.NewStringValue("logo.png")},
+		},
+		Extensions: map[string]*structpb.Value{"x-region": structpb.NewStringValue("eu")},
+		SecurityDefinitions: &v2options.SecurityDefinitions{
+			Security: map[string]*v2options.SecurityScheme{
+				"ApiKeyAuth": {
+					Type:       v2options.SecurityScheme_TYPE_API_KEY,
+					Name:       "X-API-Key",
+					In:         v2options.SecurityScheme_IN_HEADER,
+					Extensions: map[string]*structpb.Value{"x-amazon-apigateway-authtype": structpb.NewStringValue("custom")},
+				},
+			},
+		},
+	})
+	// v3 extensions win over the v2 ones of the same name
+	proto.SetExtension(file.GetOptions(), options.E_Extensions, &structpb.Struct{
+		Fields: map[string]*structpb.Value{"x-owner": structpb.NewStringValue("users-team")},
+	})
+	proto.SetExtension(file.GetService()[0].GetMethod()[0].GetOptions(), v2options.E_Openapiv2Operation, &v2options.Operation{
+		Summary: "Get a user",
+		Responses: map[string]*v2options.Response{
+			"404": {Description: "Not found"},
+		},
+	})
+	// The v3 annotation of an element with both annotations is merged into the output
+	proto.SetExtension(file.GetService()[0].GetMethod()[0].GetOptions(), options.E_Operation, &options.Operation{
+		Summary:     "Fetch a user",
+		Description: "Returns the user by id",
+	})
+	file.GetMessageType()[0].GetField()[0].Options = &descriptorpb.FieldOptions{}
+	proto.SetExtension(file.GetMessageType()[0].GetField()[0].GetOptions(), v2options.E_Openapiv2Field, &v2options.JSONSchema{
+		MaxLength: 64,
+	})
+	// Files without v2 annotations produce no output
+	other := testFile("b/v1/item.proto", "b.v1", "ItemService", "Item", "/v1/items")
+
+	gen := newTestPlugin(t, "paths=source_relative", file, other)
+	oapiGenerator := generator.NewOpenAPIGenerator(gen, &generator.Options{Migrate: true})
+	for _, f := range gen.Files {
+		require.NoError(t, oapiGenerator.Generate(f))
+	}
+	require.NoError(t, oapiGenerator.Finish())
+
+	files := responseFiles(t, gen)
+	require.Len(t, files, 1)
+	data := files["a/v1/user.openapiv3.txt"]
+	assert.Contains(t, data, `// file a/v1/user.proto
+option (protoc_gen_openapiv3.options.info) = {
+  title: "Users API"
+  version: "1.0.0"
+  extensions: {
+    key: "x-logo"
+    value: {
+      string_value: "logo.png"
+    }
+  }
+};`)
+	assert.Contains(t, data, `  extensions: {
+    key: "x-amazon-apigateway-authtype"
+    value: {
+      string_value: "custom"
+    }
+  }
+  parameter_name: "X-API-Key"
+};`)
+	assert.Contains(t, data, `option (protoc_gen_openapiv3.options.extensions) = {
+  fields: {
+    key: "x-owner"
+    value: {
+      string_value: "users-team"
+    }
+  }
+  fields: {
+    key: "x-region"
+    value: {
+      string_value: "eu"
+    }
+  }
+};`)
+	assert.Contains(t, data, "so they replace those options rather than being added next to them")
+	assert.Contains(t, data, `// rpc a.v1.UserService.GetUser
+option (protoc_gen_openapiv3.options.operation) = {
+  summary: "Fetch a user"
+  description: "Returns the user by id"
+  responses: {
+    code: "404"
+    description: "Not found"
+  }
+};`)
+	assert.Contains(t, data, `// field a.v1.User.id
+[(protoc_gen_openapiv3.options.field) = {
+  max_length: 64
+}]`)
+}
@@ -0,0 +1,243 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// renderable is implemented by every libopenapi high-level object that can be rendered to YAML
+type renderable interface {
+	Render() ([]byte, error)
+}
+
+// mergeDocuments merges the src document into dst.
+// Paths, webhooks, operation ids and components defined differently in both documents are reported as conflicts,
+// while tags, servers and security requirements are deduplicated.
+func mergeDocuments(dst, src *high.Document) error {
+	operationIDs := make(map[string]bool)
+	for _, operation := range documentOperations(dst) {
+		operationIDs[operation.OperationId] = true
+	}
+	srcOperations := documentOperations(src)
+
+	if err := mergePathItems("path", dst.Paths.PathItems, src.Paths.PathItems); err != nil {
+		return err
+	}
+
+	if src.Webhooks != nil {
+		if dst.Webhooks == nil {
+			dst.Webhooks = orderedmap.New[string, *high.PathItem]()
+		}
+		if err := mergePathItems("webhook", dst.Webhooks, src.Webhooks); err != nil {
+			return err
+		}
+	}
+
+	// Operation ids must stay unique across the merged files
+	for _, operation := range srcOperations {
+		if operation.OperationId != "" && operationIDs[operation.OperationId] {
+			return fmt.Errorf("conflicting definitions for operationId %q", operation.OperationId)
+		}
+	}
+
+	if err := mergeComponents("schema", dst.Components.Schemas, src.Components.Schemas); err != nil {
+		return err
+	}
+	if err := mergeComponents("security scheme", dst.Components.SecuritySchemes, src.Components.SecuritySchemes); err != nil {
+		return err
+	}
+	if err := mergeComponents("response", mergeTarget(&dst.Components.Responses, src.Components.Responses), src.Components.Responses); err != nil {
+		return err
+	}
+	if err := mergeComponents("parameter", mergeTarget(&dst.Components.Parameters, src.Components.Parameters), src.Components.Parameters); err != nil {
+		return err
+	}
+	if err := mergeComponents("header", mergeTarget(&dst.Components.Headers, src.Components.Headers), src.Components.Headers); err != nil {
+		return err
+	}
+	if err := mergeComponents("example", mergeTarget(&dst.Components.Examples, src.Components.Examples), src.Components.Examples); err != nil {
+		return err
+	}
+	if err := mergeComponents("request body", mergeTarget(&dst.Components.RequestBodies, src.Components.RequestBodies), src.Components.RequestBodies); err != nil {
+		return err
+	}
+	if err := mergeComponents("link", mergeTarget(&dst.Components.Links, src.Components.Links), src.Components.Links); err != nil {
+		return err
+	}
+
+	// Merge tags, keeping the first definition of each name
+	for _, tag := range src.Tags {
+		if !hasTag(dst.Tags, tag.Name) {
+			dst.Tags = append(dst.Tags, tag)
+		}
+	}
+
+	// Merge servers, keeping the first definition of each URL
+	for _, server := range src.Servers {
+		if !hasServer(dst.Servers, server.URL) {
+			dst.Servers = append(dst.Servers, server)
+		}
+	}
+
+	// Merge global security requirements, skipping identical ones
+	for _, requirement := range src.Security {
+		duplicate, err := containsRendered(dst.Security, requirement)
+		if err != nil {
+			return err
+		}
+		if !duplicate {
+			dst.Security = append(dst.Security, requirement)
+		}
+	}
+
+	if dst.ExternalDocs == nil {
+		dst.ExternalDocs = src.ExternalDocs
+	}
+	if dst.JsonSchemaDialect == "" {
+		dst.JsonSchemaDialect = src.JsonSchemaDialect
+	}
+	if src.Extensions != nil {
+		if dst.Extensions == nil {
+			dst.Extensions = orderedmap.New[string, *yaml.Node]()
+		}
+		for key, value := range src.Extensions.FromOldest() {
+			if _, exists := dst.Extensions.Get(key); !exists {
+				dst.Extensions.Set(key, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeComponents adds the src components to dst, failing when a name is defined differently in both
+func mergeComponents[T renderable](kind string, dst, src *orderedmap.Map[string, T]) error {
+	if src == nil {
+		return nil
+	}
+
+	for name, component := range src.FromOldest() {
+		existing, exists := dst.Get(name)
+		if !exists {
+			dst.Set(name, component)
+			continue
+		}
+
+		same, err := renderEqual(existing, component)
+		if err != nil {
+			return fmt.Errorf("failed to compare %s component %q: %w", kind, name, err)
+		}
+		if !same {
+			return fmt.Errorf("conflicting definitions for %s component %q", kind, name)
+		}
+	}
+
+	return nil
+}
+
+// mergeTarget returns the dst components, creating them when src has components to merge
+func mergeTarget[T any](dst **orderedmap.Map[string, T], src *orderedmap.Map[string, T]) *orderedmap.Map[string, T] {
+	if *dst == nil && src != nil {
+		*dst = orderedmap.New[string, T]()
+	}
+	return *dst
+}
+
+// mergePathItems adds the src path items to dst. Operations of a shared path item are combined,
+// failing when both define the same HTTP method.
+func mergePathItems(kind string, dst, src *orderedmap.Map[string, *high.PathItem]) error {
+	for path, item := range src.FromOldest() {
+		existing, exists := dst.Get(path)
+		if !exists {
+			dst.Set(path, item)
+			continue
+		}
+
+		srcOperations := pathItemOperations(item)
+		for i, dstOperation := range pathItemOperations(existing) {
+			if *srcOperations[i].operation == nil {
+				continue
+			}
+			if *dstOperation.operation != nil {
+				return fmt.Errorf("conflicting definitions for %s %s %s", kind, dstOperation.method, path)
+			}
+			*dstOperation.operation = *srcOperations[i].operation
+		}
+	}
+
+	return nil
+}
+
+// pathItemOperation references the operation field of a path item for a given HTTP method
+type pathItemOperation struct {
+	method    string
+	operation **high.Operation
+}
+
+// pathItemOperations lists the operation fields of a path item in a stable order
+func pathItemOperations(item *high.PathItem) []pathItemOperation {
+	return []pathItemOperation{
+		{"GET", &item.Get},
+		{"PUT", &item.Put},
+		{"POST", &item.Post},
+		{"DELETE", &item.Delete},
+		{"OPTIONS", &item.Options},
+		{"HEAD", &item.Head},
+		{"PATCH", &item.Patch},
+		{"TRACE", &item.Trace},
+	}
+}
+
+// renderEqual reports whether two objects render to the same YAML, regardless of mapping key order
+func renderEqual(a, b renderable) (bool, error) {
+	var decoded [2]interface{}
+	for i, item := range []renderable{a, b} {
+		rendered, err := item.Render()
+		if err != nil {
+			return false, err
+		}
+		if err := yaml.Unmarshal(rendered, &decoded[i]); err != nil {
+			return false, err
+		}
+	}
+	return reflect.DeepEqual(decoded[0], decoded[1]), nil
+}
+
+// containsRendered reports whether items contains an object rendering to the same YAML as item
+func containsRendered[T renderable](items []T, item T) (bool, error) {
+	for _, existing := range items {
+		same, err := renderEqual(existing, item)
+		if err != nil {
+			return false, err
+		}
+		if same {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasTag checks if a tag with the given name exists
+func hasTag(tags []*base.Tag, name string) bool {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasServer checks if a server with the given URL exists
+func hasServer(servers []*high.Server, url string) bool {
+	for _, server := range servers {
+		if server.URL == url {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	v2options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"github.com/sapk/protoc-gen-openapiv3/options"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// migrationWriter renders protoc_gen_openapiv3 annotations as proto source text
+type migrationWriter struct {
+	sb       strings.Builder
+	elements int
+}
+
+// element starts the annotations of a proto element, described by a comment
+func (w *migrationWriter) element(format string, args ...any) {
+	if w.elements > 0 {
+		w.sb.WriteString("\n")
+	}
+	w.elements++
+	fmt.Fprintf(&w.sb, "// "+format+"\n", args...)
+}
+
+// option writes an option statement setting the extension to the message
+func (w *migrationWriter) option(ext protoreflect.ExtensionType, msg proto.Message) {
+	fmt.Fprintf(&w.sb, "option (%s) = %s;\n", ext.TypeDescriptor().FullName(), migrationText(msg))
+}
+
+// fieldOption writes the bracketed option setting the extension on a field
+func (w *migrationWriter) fieldOption(ext protoreflect.ExtensionType, msg proto.Message) {
+	fmt.Fprintf(&w.sb, "[(%s) = %s]\n", ext.TypeDescriptor().FullName(), migrationText(msg))
+}
+
+// migrationFieldSeparator matches the separator following the field name starting a line of proto text
+var migrationFieldSeparator = regexp.MustCompile(`(?m)^(\s*[^\s:]+):\s+`)
+
+// migrationText renders a message as a proto text block
+func migrationText(msg proto.Message) string {
+	text := strings.TrimSpace(prototext.MarshalOptions{Multiline: true, Indent: "  "}.Format(msg))
+	// prototext randomly doubles the space after field names to keep its output unstable, normalize it
+	text = migrationFieldSeparator.ReplaceAllString(text, "$1: ")
+	if text == "" {
+		return "{}"
+	}
+	return "{\n  " + strings.ReplaceAll(text, "\n", "\n  ") + "\n}"
+}
+
+// migrate writes the protoc_gen_openapiv3 annotations equivalent to the grpc-gateway openapiv2 annotations
+// of the file, merged with the v3 annotations of the same elements the same way as during generation.
+// Files without v2 annotations produce no output.
+func (g *OpenAPIGenerator) migrate(file *protogen.File) error {
+	parsed, err := g.ParseProtoFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse file: %w", err)
+	}
+
+	w := &migrationWriter{}
+
+	if parsed.V2Swagger != nil {
+		w.element("file %s", file.Desc.Path())
+		if parsed.Info != nil {
+			w.option(options.E_Info, parsed.Info)
+		}
+		for _, server := range parsed.Servers {
+			w.option(options.E_Server, server)
+		}
+		for _, scheme := range parsed.SecuritySchemes {
+			w.option(options.E_SecurityScheme, scheme)
+		}
+		for _, requirement := range parsed.Security {
+			w.option(options.E_Security, requirement)
+		}
+		for _, tag := range parsed.Tags {
+			w.option(options.E_Tag, tag)
+		}
+		if parsed.ExternalDocs != nil {
+			w.option(options.E_ExternalDocs, parsed.ExternalDocs)
+		}
+		for _, response := range parsed.DefaultResponses {
+			w.option(options.E_DefaultResponse, response)
+		}
+		if len(parsed.Extensions) > 0 {
+			w.option(options.E_Extensions, &structpb.Struct{Fields: parsed.Extensions})
+		}
+	}
+
+	for i, service := range file.Services {
+		parsedService := parsed.Services[i]
+		if proto.HasExtension(service.Desc.Options(), v2options.E_Openapiv2Tag) {
+			w.element("service %s", service.Desc.FullName())
+			w.option(options.E_ServiceTag, parsedService.Tag)
+		}
+
+		for j, method := range service.Methods {
+			// The operation includes the servers converted from the schemes of the v2 annotation
+			if parsedMethod := parsedService.Methods[j]; parsedMethod.V2Operation != nil {
+				w.element("rpc %s", method.Desc.FullName())
+				w.option(options.E_Operation, parsedMethod.Operation)
+			}
+		}
+	}
+
+	messages := make(map[string]ParsedMessage, len(parsed.Messages))
+	for _, message := range parsed.Messages {
+		messages[message.FullName] = message
+	}
+	enums := make(map[string]ParsedEnum, len(parsed.Enums))
+	for _, enum := range parsed.Enums {
+		enums[enum.FullName] = enum
+	}
+	for _, message := range file.Messages {
+		migrateMessage(w, message, messages, enums)
+	}
+	for _, enum := range file.Enums {
+		migrateEnum(w, enum, enums)
+	}
+
+	if w.elements == 0 {
+		return nil
+	}
+
+	header := fmt.Sprintf("// protoc_gen_openapiv3 annotations converted from the openapiv2 annotations of %s.\n"+
+		"// Import \"protoc-gen-openapiv3/options/annotations.proto\" in place of \"protoc-gen-openapiv2/options/annotations.proto\".\n"+
+		"// The options below include the values of the protoc_gen_openapiv3 options already set on each element,\n"+
+		"// so they replace those options rather than being added next to them.\n\n",
+		file.Desc.Path())
+	if _, err := g.gen.NewGeneratedFile(file.GeneratedFilenamePrefix+".openapiv3.txt", "").Write([]byte(header + w.sb.String())); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// migrateMessage writes the merged annotations of a message, its fields and its nested types
+func migrateMessage(w *migrationWriter, message *protogen.Message, messages map[string]ParsedMessage, enums map[string]ParsedEnum) {
+	if message.Desc.IsMapEntry() {
+		return
+	}
+	parsed := messages[string(message.Desc.FullName())]
+
+	if proto.HasExtension(message.Desc.Options(), v2options.E_Openapiv2Schema) {
+		w.element("message %s", message.Desc.FullName())
+		w.option(options.E_Schema, parsed.Schema)
+	}
+
+	for i, field := range message.Fields {
+		if proto.HasExtension(field.Desc.Options(), v2options.E_Openapiv2Field) {
+			w.element("field %s", field.Desc.FullName())
+			w.fieldOption(options.E_Field, parsed.Fields[i].Schema)
+		}
+	}
+
+	for _, nested := range message.Messages {
+		migrateMessage(w, nested, messages, enums)
+	}
+	for _, enum := range message.Enums {
+		migrateEnum(w, enum, enums)
+	}
+}
+
+// migrateEnum writes the merged annotation of an enum
+func migrateEnum(w *migrationWriter, enum *protogen.Enum, enums map[string]ParsedEnum) {
+	if proto.HasExtension(enum.Desc.Options(), v2options.E_Openapiv2Enum) {
+		w.element("enum %s", enum.Desc.FullName())
+		w.option(options.E_EnumSchema, enums[string(enum.Desc.FullName())].Schema)
+	}
+}
@@ -0,0 +1,202 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// NamingStrategy represents how proto type names are turned into component names
+type NamingStrategy string
+
+const (
+	// NamingSimple uses the type name without its package, e.g. "User"
+	NamingSimple NamingStrategy = "simple"
+	// NamingPackage prefixes the type name with the last package element, e.g. "v1.User"
+	NamingPackage NamingStrategy = "package"
+	// NamingFQN uses the fully qualified type name, e.g. "example.v1.User"
+	NamingFQN NamingStrategy = "fqn"
+	// NamingUnique uses the shortest name suffix that is unique among all types, e.g. "User" or "a.v1.User"
+	NamingUnique NamingStrategy = "unique"
+)
+
+// namingStrategy returns the naming strategy selected by the options,
+// falling back to FQNForOpenAPIName when none is set
+func (o *Options) namingStrategy() NamingStrategy {
+	if o.NamingStrategy != "" {
+		return o.NamingStrategy
+	}
+	if o.FQNForOpenAPIName {
+		return NamingFQN
+	}
+	return NamingSimple
+}
+
+// resolveSchemaNames computes the component name of every type, keyed by fully qualified name.
+// types maps each fully qualified type name to its proto package.
+// Types whose names collide are disambiguated: the unique strategy lengthens them until they differ,
+// the other strategies fall back to the fully qualified name.
+func resolveSchemaNames(types map[string]string, strategy NamingStrategy) (map[string]string, error) {
+	segments := make(map[string][]string, len(types))
+	lengths := make(map[string]int, len(types))
+	for fullName, pkg := range types {
+		segments[fullName] = strings.Split(fullName, ".")
+		typeLength := len(strings.Split(strings.TrimPrefix(fullName, pkg+"."), "."))
+		if pkg == "" {
+			typeLength = len(segments[fullName])
+		}
+
+		switch strategy {
+		case NamingSimple, NamingUnique:
+			lengths[fullName] = typeLength
+		case NamingPackage:
+			lengths[fullName] = min(typeLength+1, len(segments[fullName]))
+		case NamingFQN:
+			lengths[fullName] = len(segments[fullName])
+		default:
+			return nil, fmt.Errorf("unsupported naming strategy %q: must be one of %s, %s, %s or %s",
+				strategy, NamingSimple, NamingPackage, NamingFQN, NamingUnique)
+		}
+	}
+
+	suffix := func(fullName string) string {
+		parts := segments[fullName]
+		return strings.Join(parts[len(parts)-lengths[fullName]:], ".")
+	}
+
+	for {
+		byName := make(map[string][]string)
+		for fullName := range types {
+			byName[suffix(fullName)] = append(byName[suffix(fullName)], fullName)
+		}
+
+		collided := false
+		for _, fullNames := range byName {
+			if len(fullNames) < 2 {
+				continue
+			}
+			for _, fullName := range fullNames {
+				if lengths[fullName] == len(segments[fullName]) {
+					continue
+				}
+				if strategy == NamingUnique {
+					lengths[fullName]++
+				} else {
+					lengths[fullName] = len(segments[fullName])
+				}
+				collided = true
+			}
+		}
+		if !collided {
+			break
+		}
+	}
+
+	names := make(map[string]string, len(types))
+	for fullName := range types {
+		names[fullName] = suffix(fullName)
+	}
+	return names, nil
+}
+
+// parsedFileTypes lists the types defined or referenced by a parsed file, keyed by fully qualified name.
+// Referenced types are assumed to be top-level types of their package.
+func parsedFileTypes(parsedFile *ParsedFile) map[string]string {
+	types := make(map[string]string)
+	addReferenced := func(name string) {
+		name = strings.TrimPrefix(name, ".")
+		if _, exists := types[name]; exists || !strings.Contains(name, ".") {
+			return
+		}
+		types[name] = name[:strings.LastIndex(name, ".")]
+	}
+
+	for _, msg := range parsedFile.Messages {
+		types[msg.fullName(parsedFile.Package)] = parsedFile.Package
+	}
+	for _, enum := range parsedFile.Enums {
+		types[enum.fullName(parsedFile.Package)] = parsedFile.Package
+	}
+	for _, msg := range parsedFile.Messages {
+		for _, field := range msg.Fields {
+			fieldType := strings.TrimPrefix(strings.TrimPrefix(field.Type, "repeated "), "optional ")
+			if !strings.HasPrefix(fieldType, "map<") {
+				addReferenced(fieldType)
+			}
+		}
+	}
+	for _, service := range parsedFile.Services {
+		for _, method := range service.Methods {
+			addReferenced(method.InputType)
+			addReferenced(method.OutputType)
+			if method.ServerStreaming {
+				addStatusTypes(types)
+			}
+		}
+	}
+
+	return types
+}
+
+// schemaTypes lists the types of every file to generate and the types they reference,
+// keyed by fully qualified name, so that component names are consistent across files
+func schemaTypes(gen *protogen.Plugin) map[string]string {
+	types := make(map[string]string)
+	addMessage := func(msg *protogen.Message) {
+		types[string(msg.Desc.FullName())] = string(msg.Desc.ParentFile().Package())
+	}
+	addEnum := func(enum *protogen.Enum) {
+		types[string(enum.Desc.FullName())] = string(enum.Desc.ParentFile().Package())
+	}
+	addField := func(field *protogen.Field) {
+		if field.Desc.IsMap() {
+			field = field.Message.Fields[1]
+		}
+		if field.Message != nil {
+			addMessage(field.Message)
+		}
+		if field.Enum != nil {
+			addEnum(field.Enum)
+		}
+	}
+
+	var walk func(messages []*protogen.Message)
+	walk = func(messages []*protogen.Message) {
+		for _, msg := range messages {
+			if msg.Desc.IsMapEntry() {
+				continue
+			}
+			addMessage(msg)
+			for _, enum := range msg.Enums {
+				addEnum(enum)
+			}
+			for _, field := range msg.Fields {
+				addField(field)
+			}
+			walk(msg.Messages)
+		}
+	}
+
+	for _, file := range gen.Files {
+		if !file.Generate {
+			continue
+		}
+		walk(file.Messages)
+		for _, enum := range file.Enums {
+			addEnum(enum)
+		}
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				addMessage(method.Input)
+				addMessage(method.Output)
+				// Stream envelopes reference the error model
+				if method.Desc.IsStreamingServer() {
+					addStatusTypes(types)
+				}
+			}
+		}
+	}
+
+	return types
+}
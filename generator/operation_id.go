@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	high "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// OperationIDCase represents the letter case applied to generated operationIds
+type OperationIDCase string
+
+const (
+	// OperationIDCaseNone keeps the rendered template as is, e.g. "UserService_GetUser"
+	OperationIDCaseNone OperationIDCase = ""
+	// OperationIDCaseCamel converts the rendered template to lower camel case, e.g. "userServiceGetUser"
+	OperationIDCaseCamel OperationIDCase = "camel"
+	// OperationIDCaseSnake converts the rendered template to snake case, e.g. "user_service_get_user"
+	OperationIDCaseSnake OperationIDCase = "snake"
+)
+
+// DefaultOperationIDTemplate is the operationId template used when none is configured
+const DefaultOperationIDTemplate = "{Method}"
+
+// operationIDTemplate returns the configured operationId template or the default one
+func (o *Options) operationIDTemplate() string {
+	if o.OperationIDTemplate == "" {
+		return DefaultOperationIDTemplate
+	}
+	return o.OperationIDTemplate
+}
+
+// validateOperationIDOptions checks the operationId template and case of the options
+func validateOperationIDOptions(opts *Options) error {
+	rendered := strings.NewReplacer("{Method}", "", "{Service}", "", "{package}", "").Replace(opts.operationIDTemplate())
+	if strings.ContainsAny(rendered, "{}") {
+		return fmt.Errorf("unsupported operationId template %q: placeholders must be {Method}, {Service} or {package}", opts.OperationIDTemplate)
+	}
+
+	switch opts.OperationIDCase {
+	case OperationIDCaseNone, OperationIDCaseCamel, OperationIDCaseSnake:
+		return nil
+	default:
+		return fmt.Errorf("unsupported operationId case %q: must be %s or %s", opts.OperationIDCase, OperationIDCaseCamel, OperationIDCaseSnake)
+	}
+}
+
+// operationID returns the operationId of a method, either set by its operation annotation
+// or rendered from the operationId template
+func operationID(parsedFile *ParsedFile, service ParsedService, method ParsedMethod, opts *Options) string {
+	if id := method.Operation.GetOperationId(); id != "" {
+		return id
+	}
+
+	id := strings.NewReplacer(
+		"{Method}", method.Name,
+		"{Service}", service.Name,
+		"{package}", parsedFile.Package,
+	).Replace(opts.operationIDTemplate())
+	return applyOperationIDCase(id, opts.OperationIDCase)
+}
+
+// validateExplicitOperationIDs checks that no two methods set the same operationId in their annotations
+func validateExplicitOperationIDs(parsedFile *ParsedFile) error {
+	owners := make(map[string]string)
+	for _, service := range parsedFile.Services {
+		for _, method := range service.Methods {
+			id := method.Operation.GetOperationId()
+			if id == "" {
+				continue
+			}
+			owner := service.Name + "." + method.Name
+			if previous, exists := owners[id]; exists {
+				return fmt.Errorf("duplicate operationId %q on methods %s and %s", id, previous, owner)
+			}
+			owners[id] = owner
+		}
+	}
+	return nil
+}
+
+// ensureUniqueOperationIDs suffixes the operationIds that are used by several operations,
+// such as the additional bindings of a method. Annotated operations keep their id.
+func ensureUniqueOperationIDs(doc *high.Document, annotated []*high.Operation, opts *Options) {
+	used := make(map[string]bool)
+	kept := make(map[*high.Operation]bool)
+	for _, operation := range annotated {
+		used[operation.OperationId] = true
+		kept[operation] = true
+	}
+
+	for _, operation := range documentOperations(doc) {
+		if kept[operation] || operation.OperationId == "" {
+			continue
+		}
+		if !used[operation.OperationId] {
+			used[operation.OperationId] = true
+			continue
+		}
+
+		for n := 2; ; n++ {
+			candidate := operationIDWithSuffix(operation.OperationId, n, opts.OperationIDCase)
+			if !used[candidate] {
+				operation.OperationId = candidate
+				used[candidate] = true
+				break
+			}
+		}
+	}
+}
+
+// operationIDWithSuffix appends a number to an operationId, following its letter case
+func operationIDWithSuffix(id string, n int, idCase OperationIDCase) string {
+	if idCase == OperationIDCaseCamel {
+		return id + strconv.Itoa(n)
+	}
+	return id + "_" + strconv.Itoa(n)
+}
+
+// documentOperations lists the operations of the paths, their callbacks and the webhooks of a document
+func documentOperations(doc *high.Document) []*high.Operation {
+	var operations []*high.Operation
+
+	var walk func(item *high.PathItem)
+	walk = func(item *high.PathItem) {
+		if item == nil {
+			return
+		}
+		for _, op := range pathItemOperations(item) {
+			operation := *op.operation
+			if operation == nil {
+				continue
+			}
+			operations = append(operations, operation)
+			for _, callback := range operation.Callbacks.FromOldest() {
+				for _, callbackItem := range callback.Expression.FromOldest() {
+					walk(callbackItem)
+				}
+			}
+		}
+	}
+
+	if doc.Paths != nil {
+		for _, item := range doc.Paths.PathItems.FromOldest() {
+			walk(item)
+		}
+	}
+	for _, item := range doc.Webhooks.FromOldest() {
+		walk(item)
+	}
+
+	return operations
+}
+
+// applyOperationIDCase converts an operationId to the given letter case
+func applyOperationIDCase(id string, idCase OperationIDCase) string {
+	if idCase == OperationIDCaseNone {
+		return id
+	}
+
+	words := splitWords(id)
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if idCase == OperationIDCaseCamel && i > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		words[i] = word
+	}
+
+	if idCase == OperationIDCaseSnake {
+		return strings.Join(words, "_")
+	}
+	return strings.Join(words, "")
+}
+
+// splitWords splits an identifier into words on separators and case changes,
+// keeping acronyms together, e.g. "user.v1.GetHTTPRule" gives [user v1 Get HTTP Rule]
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			continue
+		}
+
+		if len(current) > 0 && unicode.IsUpper(r) {
+			previous := current[len(current)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(previous) || nextIsLower {
+				words = append(words, string(current))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+
+	return words
+}
@@ -2,10 +2,14 @@ package generator
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"google.golang.org/genproto/googleapis/api/annotations"
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	v2options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
 	"github.com/sapk/protoc-gen-openapiv3/options"
@@ -13,27 +17,42 @@ import (
 
 // ParsedFile represents the parsed proto file with all necessary information
 type ParsedFile struct {
-	Package         string
-	Services        []ParsedService
-	Messages        []ParsedMessage
-	Enums           []ParsedEnum
-	Imports         []string
-	Annotations     map[string]string
-	Info            *options.Info
-	Servers         []*options.Server
-	SecuritySchemes []*options.SecurityScheme
-	Security        []*options.SecurityRequirement
-	Tags            []*options.Tag
-	ExternalDocs    *options.ExternalDocumentation
-	V2Swagger       *v2options.Swagger
+	Package           string
+	Services          []ParsedService
+	Messages          []ParsedMessage
+	Enums             []ParsedEnum
+	Imports           []string
+	Annotations       map[string]string
+	Info              *options.Info
+	Servers           []*options.Server
+	SecuritySchemes   []*options.SecurityScheme
+	Security          []*options.SecurityRequirement
+	Tags              []*options.Tag
+	ExternalDocs      *options.ExternalDocumentation
+	Extensions        map[string]*structpb.Value
+	JSONSchemaDialect string
+	Components        *options.Components
+	DefaultResponses  []*options.Response // Responses added to every operation
+	SchemaNames       map[string]string   // Component name of each type, keyed by fully qualified name
+	CallbackServices  []ParsedService     // Services of other files holding the RPCs referenced by callbacks
+	V2Swagger         *v2options.Swagger
 }
 
 // ParsedService represents a parsed service definition
 type ParsedService struct {
-	Name        string
-	Methods     []ParsedMethod
-	Annotations map[string]string
-	Comment     string
+	Name             string
+	FullName         string
+	Methods          []ParsedMethod
+	Annotations      map[string]string
+	Comment          string
+	DefaultResponses []*options.Response // Responses added to every operation of the service
+	Webhook          *options.Webhook
+	Tag              *options.Tag // Tag grouping the operations of the service, merging the serviceTag and openapiv2_tag options
+}
+
+// fullName returns the fully qualified name of the service, derived from the package when unset
+func (s ParsedService) fullName(pkg string) string {
+	return qualifiedName(s.FullName, s.Name, pkg)
 }
 
 // ParsedMethod represents a parsed method definition
@@ -51,14 +70,43 @@ type ParsedMethod struct {
 	Responses   []*options.Response
 	RequestBody *options.RequestBody
 	Parameters  []*options.Parameter
+	Webhook     *options.Webhook
+	Callbacks   []*options.Callback
+	// CallbackTarget is set when a callback of any input file references the method
+	CallbackTarget bool
+	V2Operation    *v2options.Operation // Operation annotation of grpc-gateway, merged into Operation
+
+	AdditionalBindings []ParsedHTTPBinding
+	ClientStreaming    bool
+	ServerStreaming    bool
+}
+
+// ParsedHTTPBinding represents an additional HTTP route of a method
+type ParsedHTTPBinding struct {
+	HTTPMethod string
+	HTTPPath   string
+	HTTPBody   string
+}
+
+// callbackOnly reports whether the method has no HTTP rule and is only documented as the callback of another RPC
+func (m ParsedMethod) callbackOnly() bool {
+	return m.CallbackTarget && m.HTTPPath == "" && len(m.AdditionalBindings) == 0
 }
 
 // ParsedMessage represents a parsed message definition
 type ParsedMessage struct {
 	Name        string
+	FullName    string
 	Fields      []ParsedField
 	Annotations map[string]string
 	Comment     string
+	// Schema annotates the message schema, merging the schema and openapiv2_schema options
+	Schema *options.Schema
+}
+
+// fullName returns the fully qualified name of the message, derived from the package when unset
+func (m ParsedMessage) fullName(pkg string) string {
+	return qualifiedName(m.FullName, m.Name, pkg)
 }
 
 // ParsedField represents a parsed field definition
@@ -68,14 +116,35 @@ type ParsedField struct {
 	Number      int32
 	Annotations map[string]string
 	Comment     string
+	// Schema annotates the field schema, merging the field and openapiv2_field options
+	Schema *options.Schema
 }
 
 // ParsedEnum represents a parsed enum definition
 type ParsedEnum struct {
 	Name        string
+	FullName    string
 	Values      []ParsedEnumValue
 	Annotations map[string]string
 	Comment     string
+	// Schema annotates the enum schema, merging the enumSchema and openapiv2_enum options
+	Schema *options.Schema
+}
+
+// fullName returns the fully qualified name of the enum, derived from the package when unset
+func (e ParsedEnum) fullName(pkg string) string {
+	return qualifiedName(e.FullName, e.Name, pkg)
+}
+
+// qualifiedName returns fullName when set, or name qualified with the package
+func qualifiedName(fullName, name, pkg string) string {
+	if fullName != "" {
+		return fullName
+	}
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
 }
 
 // ParsedEnumValue represents a parsed enum value
@@ -87,6 +156,53 @@ type ParsedEnumValue struct {
 
 // ParseProtoFile parses a proto file and extracts all necessary information
 func (g *OpenAPIGenerator) ParseProtoFile(file *protogen.File) (*ParsedFile, error) {
+	// Resolve component names once for all the files to generate
+	if g.schemaNames == nil {
+		schemaNames, err := g.resolveInputSchemaNames()
+		if err != nil {
+			return nil, err
+		}
+		g.schemaNames = schemaNames
+	}
+
+	// Reuse the file parsed while resolving the names, so that warnings are reported once
+	parsed, found := g.prescanned[file]
+	if found {
+		delete(g.prescanned, file)
+	} else {
+		var err error
+		if parsed, err = g.parseProtoFile(file); err != nil {
+			return nil, err
+		}
+	}
+	parsed.SchemaNames = g.schemaNames
+	return parsed, nil
+}
+
+// resolveInputSchemaNames computes the component names of the types of every file to generate.
+// The error types are only named when a generated default response references them.
+func (g *OpenAPIGenerator) resolveInputSchemaNames() (map[string]string, error) {
+	types := schemaTypes(g.gen)
+	g.prescanned = make(map[*protogen.File]*ParsedFile)
+	for _, file := range g.gen.Files {
+		if !file.Generate {
+			continue
+		}
+		parsed, err := g.parseProtoFile(file)
+		if err != nil {
+			return nil, err
+		}
+		g.prescanned[file] = parsed
+		if usesDefaultErrorResponse(parsed, g.options) {
+			addErrorTypes(types, g.options)
+			break
+		}
+	}
+	return resolveSchemaNames(types, g.options.namingStrategy())
+}
+
+// parseProtoFile parses a proto file, without resolving the component names of its types
+func (g *OpenAPIGenerator) parseProtoFile(file *protogen.File) (*ParsedFile, error) {
 	parsed := &ParsedFile{
 		Package:         string(file.Desc.Package()),
 		Annotations:     make(map[string]string),
@@ -100,6 +216,11 @@ func (g *OpenAPIGenerator) ParseProtoFile(file *protogen.File) (*ParsedFile, err
 		Tags:            make([]*options.Tag, 0),
 	}
 
+	// Find the RPCs referenced by callbacks once for all the input files
+	if g.callbackTargets == nil {
+		g.callbackTargets = inputCallbackTargets(g.gen)
+	}
+
 	// Parse imports
 	for i := 0; i < file.Desc.Imports().Len(); i++ {
 		imp := file.Desc.Imports().Get(i)
@@ -162,6 +283,42 @@ func (g *OpenAPIGenerator) ParseProtoFile(file *protogen.File) (*ParsedFile, err
 			}
 		}
 
+		// Parse OpenAPI document Extensions options
+		extensionsExt := proto.GetExtension(file.Desc.Options(), options.E_Extensions)
+		if extensionsExt != nil {
+			extensions, ok := extensionsExt.(*structpb.Struct)
+			if ok {
+				parsed.Extensions = extensions.GetFields()
+			}
+		}
+
+		// Parse OpenAPI JSON Schema dialect option
+		dialectExt := proto.GetExtension(file.Desc.Options(), options.E_JsonSchemaDialect)
+		if dialectExt != nil {
+			dialect, ok := dialectExt.(string)
+			if ok {
+				parsed.JSONSchemaDialect = dialect
+			}
+		}
+
+		// Parse OpenAPI reusable Components options
+		componentsExt := proto.GetExtension(file.Desc.Options(), options.E_Components)
+		if componentsExt != nil {
+			components, ok := componentsExt.(*options.Components)
+			if ok {
+				parsed.Components = components
+			}
+		}
+
+		// Parse OpenAPI default Responses options
+		defaultResponsesExt := proto.GetExtension(file.Desc.Options(), options.E_DefaultResponse)
+		if defaultResponsesExt != nil {
+			responses, ok := defaultResponsesExt.([]*options.Response)
+			if ok {
+				parsed.DefaultResponses = responses
+			}
+		}
+
 		// Parse v2 Swagger options
 		v2SwaggerExt := proto.GetExtension(file.Desc.Options(), v2options.E_Openapiv2Swagger)
 		if v2SwaggerExt != nil {
@@ -181,41 +338,283 @@ func (g *OpenAPIGenerator) ParseProtoFile(file *protogen.File) (*ParsedFile, err
 		parsed.Services = append(parsed.Services, parsedService)
 	}
 
-	// Parse messages
-	for _, message := range file.Messages {
+	// Parse messages and enums, nested types included
+	if err := g.parseTypes(parsed, file.Messages, file.Enums); err != nil {
+		return nil, err
+	}
+
+	// Parse the services of other files holding the RPCs referenced by callbacks
+	if err := g.parseCallbackServices(parsed); err != nil {
+		return nil, err
+	}
+
+	// Parse the types of other files referenced by the file, so that their schemas are rendered
+	if err := g.parseDependencies(parsed, file); err != nil {
+		return nil, err
+	}
+
+	// Convert v2 annotations to v3 format
+	if err := g.convertV2ToV3(parsed); err != nil {
+		return nil, fmt.Errorf("failed to convert v2 to v3: %w", err)
+	}
+
+	return parsed, nil
+}
+
+// parseTypes parses the messages and enums, and the types nested in the messages
+func (g *OpenAPIGenerator) parseTypes(parsed *ParsedFile, messages []*protogen.Message, enums []*protogen.Enum) error {
+	for _, message := range messages {
+		// Map entries are rendered as the additional properties of their field
+		if message.Desc.IsMapEntry() {
+			continue
+		}
 		parsedMessage, err := g.parseMessage(message)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse message %s: %w", message.Desc.Name(), err)
+			return fmt.Errorf("failed to parse message %s: %w", message.Desc.Name(), err)
 		}
 		parsed.Messages = append(parsed.Messages, parsedMessage)
+		if err := g.parseTypes(parsed, message.Messages, message.Enums); err != nil {
+			return err
+		}
 	}
 
-	// Parse enums
-	for _, enum := range file.Enums {
+	for _, enum := range enums {
 		parsedEnum, err := g.parseEnum(enum)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse enum %s: %w", enum.Desc.Name(), err)
+			return fmt.Errorf("failed to parse enum %s: %w", enum.Desc.Name(), err)
 		}
 		parsed.Enums = append(parsed.Enums, parsedEnum)
 	}
+	return nil
+}
 
-	// Convert v2 annotations to v3 format
-	if err := g.convertV2ToV3(parsed); err != nil {
-		return nil, fmt.Errorf("failed to convert v2 to v3: %w", err)
+// parseDependencies parses the messages and enums of other files referenced by the messages, methods and callbacks
+// of the file or by the custom error response, and the types they reference in turn.
+// Well-known types have a predefined schema and are not parsed.
+func (g *OpenAPIGenerator) parseDependencies(parsed *ParsedFile, file *protogen.File) error {
+	parsedTypes := make(map[string]bool)
+	for _, message := range parsed.Messages {
+		parsedTypes[message.FullName] = true
+	}
+	for _, enum := range parsed.Enums {
+		parsedTypes[enum.FullName] = true
 	}
 
-	return parsed, nil
+	var walkMessages func(messages []*protogen.Message) error
+	var parseDependency func(message *protogen.Message) error
+	walkFields := func(fields []*protogen.Field) error {
+		for _, field := range fields {
+			if field.Desc.IsMap() {
+				field = field.Message.Fields[1]
+			}
+			if field.Enum != nil && !parsedTypes[string(field.Enum.Desc.FullName())] {
+				parsedTypes[string(field.Enum.Desc.FullName())] = true
+				parsedEnum, err := g.parseEnum(field.Enum)
+				if err != nil {
+					return fmt.Errorf("failed to parse enum %s: %w", field.Enum.Desc.FullName(), err)
+				}
+				parsed.Enums = append(parsed.Enums, parsedEnum)
+			}
+			if field.Message != nil {
+				if err := parseDependency(field.Message); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	walkMessages = func(messages []*protogen.Message) error {
+		for _, message := range messages {
+			if message.Desc.IsMapEntry() {
+				continue
+			}
+			if err := walkFields(message.Fields); err != nil {
+				return err
+			}
+			if err := walkMessages(message.Messages); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	parseDependency = func(message *protogen.Message) error {
+		fullName := string(message.Desc.FullName())
+		if parsedTypes[fullName] || isWellKnownType(fullName) {
+			return nil
+		}
+		parsedTypes[fullName] = true
+		parsedMessage, err := g.parseMessage(message)
+		if err != nil {
+			return fmt.Errorf("failed to parse message %s: %w", fullName, err)
+		}
+		parsed.Messages = append(parsed.Messages, parsedMessage)
+		return walkFields(message.Fields)
+	}
+
+	if err := walkMessages(file.Messages); err != nil {
+		return err
+	}
+
+	services := slices.Clone(file.Services)
+	for _, target := range slices.Sorted(maps.Keys(callbackTargets(parsed))) {
+		if service := g.findService(target); service != nil && !slices.Contains(services, service) {
+			services = append(services, service)
+		}
+	}
+	for _, service := range services {
+		for _, method := range service.Methods {
+			if err := parseDependency(method.Input); err != nil {
+				return err
+			}
+			if err := parseDependency(method.Output); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The custom error message may be defined in another file
+	if errorType := g.options.DefaultErrorType; errorType != "" && !g.options.DisableDefaultErrors {
+		if _, found := lookupType(parsed, errorType); !found {
+			message := g.findMessage(errorType)
+			if message == nil {
+				return fmt.Errorf("default error type %q not found", errorType)
+			}
+			if err := parseDependency(message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// callbackTargets returns the fully qualified names of the RPCs referenced by the callbacks of the file
+func callbackTargets(parsedFile *ParsedFile) map[string]bool {
+	targets := make(map[string]bool)
+	for _, service := range parsedFile.Services {
+		for _, method := range service.Methods {
+			for _, callback := range method.Callbacks {
+				targets[strings.TrimPrefix(callback.GetRpc(), ".")] = true
+			}
+		}
+	}
+	return targets
+}
+
+// inputCallbackTargets returns the fully qualified names of the RPCs referenced by the callbacks of every input file
+func inputCallbackTargets(gen *protogen.Plugin) map[string]bool {
+	targets := make(map[string]bool)
+	for _, file := range gen.Files {
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				callbacks, _ := proto.GetExtension(method.Desc.Options(), options.E_Callback).([]*options.Callback)
+				for _, callback := range callbacks {
+					targets[strings.TrimPrefix(callback.GetRpc(), ".")] = true
+				}
+			}
+		}
+	}
+	return targets
+}
+
+// parseCallbackServices parses the services of other files holding the RPCs referenced by the callbacks of the file
+func (g *OpenAPIGenerator) parseCallbackServices(parsed *ParsedFile) error {
+	for _, target := range slices.Sorted(maps.Keys(callbackTargets(parsed))) {
+		if _, _, found := findMethod(parsed, target); found {
+			continue
+		}
+		// Unknown RPCs are reported by the converter
+		service := g.findService(target)
+		if service == nil {
+			continue
+		}
+		parsedService, err := g.parseService(service)
+		if err != nil {
+			return fmt.Errorf("failed to parse service %s: %w", service.Desc.FullName(), err)
+		}
+		parsed.CallbackServices = append(parsed.CallbackServices, parsedService)
+	}
+	return nil
+}
+
+// findService finds the service of any input file holding a method, given the fully qualified name of the method
+func (g *OpenAPIGenerator) findService(methodFullName string) *protogen.Service {
+	for _, file := range g.gen.Files {
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				if string(method.Desc.FullName()) == methodFullName {
+					return service
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findMessage finds a message of any input file by its fully qualified name
+func (g *OpenAPIGenerator) findMessage(fullName string) *protogen.Message {
+	var find func(messages []*protogen.Message) *protogen.Message
+	find = func(messages []*protogen.Message) *protogen.Message {
+		for _, message := range messages {
+			if string(message.Desc.FullName()) == fullName {
+				return message
+			}
+			if nested := find(message.Messages); nested != nil {
+				return nested
+			}
+		}
+		return nil
+	}
+
+	for _, file := range g.gen.Files {
+		if message := find(file.Messages); message != nil {
+			return message
+		}
+	}
+	return nil
 }
 
 // parseService parses a service definition
 func (g *OpenAPIGenerator) parseService(service *protogen.Service) (ParsedService, error) {
 	parsed := ParsedService{
 		Name:        string(service.Desc.Name()),
+		FullName:    string(service.Desc.FullName()),
 		Methods:     make([]ParsedMethod, 0),
 		Annotations: make(map[string]string),
 		Comment:     string(service.Comments.Leading),
 	}
 
+	// Parse OpenAPI Webhooks annotation
+	if service.Desc.Options() != nil {
+		webhookExt := proto.GetExtension(service.Desc.Options(), options.E_Webhooks)
+		if webhookExt != nil {
+			webhook, ok := webhookExt.(*options.Webhook)
+			if ok && webhook != nil {
+				parsed.Webhook = webhook
+			}
+		}
+
+		// Parse OpenAPI service default Responses annotation
+		defaultResponsesExt := proto.GetExtension(service.Desc.Options(), options.E_ServiceDefaultResponse)
+		if defaultResponsesExt != nil {
+			responses, ok := defaultResponsesExt.([]*options.Response)
+			if ok {
+				parsed.DefaultResponses = responses
+			}
+		}
+
+		// Parse OpenAPI service Tag annotation
+		tag, ok := proto.GetExtension(service.Desc.Options(), options.E_ServiceTag).(*options.Tag)
+		if ok && tag != nil {
+			parsed.Tag = tag
+		}
+
+		// Parse v2 Tag annotation, merged into the v3 annotation
+		v2Tag, ok := proto.GetExtension(service.Desc.Options(), v2options.E_Openapiv2Tag).(*v2options.Tag)
+		if ok && v2Tag != nil {
+			parsed.Tag = mergeV2Annotation(parsed.Tag, convertV2TagToV3(v2Tag), "tag of service "+parsed.Name)
+		}
+	}
+
 	// Parse methods
 	for _, method := range service.Methods {
 		parsedMethod, err := g.parseMethod(method)
@@ -239,34 +638,25 @@ func (g *OpenAPIGenerator) parseMethod(method *protogen.Method) (ParsedMethod, e
 		Security:    make([]*options.SecurityRequirement, 0),
 		Responses:   make([]*options.Response, 0),
 		Parameters:  make([]*options.Parameter, 0),
+
+		CallbackTarget:  g.callbackTargets[string(method.Desc.FullName())],
+		ClientStreaming: method.Desc.IsStreamingClient(),
+		ServerStreaming: method.Desc.IsStreamingServer(),
 	}
 
 	// Parse HTTP annotations
 	if method.Desc.Options() != nil {
 		httpRule := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
 		if httpRule != nil {
-			// Parse HTTP method and path
-			switch {
-			case httpRule.GetGet() != "":
-				parsed.HTTPMethod = "GET"
-				parsed.HTTPPath = httpRule.GetGet()
-			case httpRule.GetPost() != "":
-				parsed.HTTPMethod = "POST"
-				parsed.HTTPPath = httpRule.GetPost()
-			case httpRule.GetPut() != "":
-				parsed.HTTPMethod = "PUT"
-				parsed.HTTPPath = httpRule.GetPut()
-			case httpRule.GetDelete() != "":
-				parsed.HTTPMethod = "DELETE"
-				parsed.HTTPPath = httpRule.GetDelete()
-			case httpRule.GetPatch() != "":
-				parsed.HTTPMethod = "PATCH"
-				parsed.HTTPPath = httpRule.GetPatch()
-			}
-
-			// Parse body field
-			if httpRule.Body != "" {
-				parsed.HTTPBody = httpRule.Body
+			// Parse HTTP method, path and body field
+			binding := parseHTTPRule(httpRule)
+			parsed.HTTPMethod = binding.HTTPMethod
+			parsed.HTTPPath = binding.HTTPPath
+			parsed.HTTPBody = binding.HTTPBody
+
+			// Parse additional bindings exposing the method on other routes
+			for _, rule := range httpRule.GetAdditionalBindings() {
+				parsed.AdditionalBindings = append(parsed.AdditionalBindings, parseHTTPRule(rule))
 			}
 		}
 
@@ -287,32 +677,87 @@ func (g *OpenAPIGenerator) parseMethod(method *protogen.Method) (ParsedMethod, e
 			}
 		}
 
-		// Parse v2 Operation annotation
-		v2OperationExt := proto.GetExtension(method.Desc.Options(), v2options.E_Openapiv2Operation)
-		if v2OperationExt != nil {
-			v2Operation, ok := v2OperationExt.(*v2options.Operation)
+		// Parse OpenAPI Webhook annotation
+		webhookExt := proto.GetExtension(method.Desc.Options(), options.E_Webhook)
+		if webhookExt != nil {
+			webhook, ok := webhookExt.(*options.Webhook)
+			if ok && webhook != nil {
+				parsed.Webhook = webhook
+			}
+		}
+
+		// Parse OpenAPI Callback annotations
+		callbacksExt := proto.GetExtension(method.Desc.Options(), options.E_Callback)
+		if callbacksExt != nil {
+			callbacks, ok := callbacksExt.([]*options.Callback)
 			if ok {
-				// Convert v2 operation to v3 format
-				parsed.Operation = convertV2OperationToV3(v2Operation)
-				// Parse security requirements if present
-				parsed.Security = parsed.Operation.GetSecurity()
-				// Parse responses
-				parsed.Responses = parsed.Operation.GetResponses()
-				// Parse request body if present
-				parsed.RequestBody = parsed.Operation.GetRequestBody()
-				// Parse parameters if present
-				parsed.Parameters = parsed.Operation.GetParameters()
+				parsed.Callbacks = callbacks
 			}
 		}
+
+		// Parse v2 Operation annotation, merged into the v3 annotation which wins field by field
+		v2Operation, ok := proto.GetExtension(method.Desc.Options(), v2options.E_Openapiv2Operation).(*v2options.Operation)
+		// An unset extension is a typed nil that must not replace the v3 annotation
+		if ok && v2Operation != nil {
+			element := fmt.Sprintf("method %s", parsed.Name)
+			converted := convertV2OperationToV3(v2Operation)
+			// Responses and parameters are matched by code and by name
+			responses := mergeV2AnnotationsByKey(parsed.Operation.GetResponses(), converted.GetResponses(),
+				(*options.Response).GetCode, "response of "+element)
+			parameters := mergeV2AnnotationsByKey(parsed.Operation.GetParameters(), converted.GetParameters(),
+				(*options.Parameter).GetName, "parameter of "+element)
+			converted.Responses, converted.Parameters = nil, nil
+
+			operation := mergeV2Annotation(parsed.Operation, converted, element)
+			operation.Responses = responses
+			operation.Parameters = parameters
+
+			parsed.V2Operation = v2Operation
+			parsed.Operation = operation
+			// Parse security requirements if present
+			parsed.Security = operation.GetSecurity()
+			// Parse responses
+			parsed.Responses = operation.GetResponses()
+			// Parse request body if present
+			parsed.RequestBody = operation.GetRequestBody()
+			// Parse parameters if present
+			parsed.Parameters = operation.GetParameters()
+		}
 	}
 
 	return parsed, nil
 }
 
+// parseHTTPRule parses the HTTP method, path and body field of a google.api.http rule
+func parseHTTPRule(httpRule *annotations.HttpRule) ParsedHTTPBinding {
+	binding := ParsedHTTPBinding{HTTPBody: httpRule.GetBody()}
+
+	switch {
+	case httpRule.GetGet() != "":
+		binding.HTTPMethod = "GET"
+		binding.HTTPPath = httpRule.GetGet()
+	case httpRule.GetPost() != "":
+		binding.HTTPMethod = "POST"
+		binding.HTTPPath = httpRule.GetPost()
+	case httpRule.GetPut() != "":
+		binding.HTTPMethod = "PUT"
+		binding.HTTPPath = httpRule.GetPut()
+	case httpRule.GetDelete() != "":
+		binding.HTTPMethod = "DELETE"
+		binding.HTTPPath = httpRule.GetDelete()
+	case httpRule.GetPatch() != "":
+		binding.HTTPMethod = "PATCH"
+		binding.HTTPPath = httpRule.GetPatch()
+	}
+
+	return binding
+}
+
 // parseMessage parses a message definition
 func (g *OpenAPIGenerator) parseMessage(message *protogen.Message) (ParsedMessage, error) {
 	parsed := ParsedMessage{
 		Name:        string(message.Desc.Name()),
+		FullName:    string(message.Desc.FullName()),
 		Fields:      make([]ParsedField, 0),
 		Annotations: make(map[string]string),
 		Comment:     string(message.Comments.Leading),
@@ -327,6 +772,18 @@ func (g *OpenAPIGenerator) parseMessage(message *protogen.Message) (ParsedMessag
 		parsed.Fields = append(parsed.Fields, parsedField)
 	}
 
+	// Parse OpenAPI Schema annotation
+	schema, ok := proto.GetExtension(message.Desc.Options(), options.E_Schema).(*options.Schema)
+	if ok && schema != nil {
+		parsed.Schema = schema
+	}
+
+	// Parse v2 Schema annotation, merged into the v3 annotation
+	v2Schema, ok := proto.GetExtension(message.Desc.Options(), v2options.E_Openapiv2Schema).(*v2options.Schema)
+	if ok && v2Schema != nil {
+		parsed.Schema = mergeV2Annotation(parsed.Schema, convertV2MessageSchemaToV3(v2Schema), "schema of message "+parsed.FullName)
+	}
+
 	return parsed, nil
 }
 
@@ -343,7 +800,7 @@ func (g *OpenAPIGenerator) parseField(field *protogen.Field) (ParsedField, error
 	switch {
 	case field.Desc.IsMap():
 		keyType := field.Message.Fields[0].Desc.Kind().String()
-		valueType := field.Message.Fields[1].Desc.Kind().String()
+		valueType := getFieldType(field.Message.Fields[1])
 		parsed.Type = fmt.Sprintf("map<%s, %s>", keyType, valueType)
 	case field.Desc.IsList():
 		parsed.Type = fmt.Sprintf("repeated %s", getFieldType(field))
@@ -353,6 +810,19 @@ func (g *OpenAPIGenerator) parseField(field *protogen.Field) (ParsedField, error
 		parsed.Type = getFieldType(field)
 	}
 
+	// Parse OpenAPI Field annotation
+	schema, ok := proto.GetExtension(field.Desc.Options(), options.E_Field).(*options.Schema)
+	if ok && schema != nil {
+		parsed.Schema = schema
+	}
+
+	// Parse v2 Field annotation, merged into the v3 annotation
+	v2Field, ok := proto.GetExtension(field.Desc.Options(), v2options.E_Openapiv2Field).(*v2options.JSONSchema)
+	if ok && v2Field != nil {
+		parsed.Schema = mergeV2Annotation(parsed.Schema, convertV2FieldSchemaToV3(v2Field, field.Desc.IsList()),
+			"schema of field "+string(field.Desc.FullName()))
+	}
+
 	return parsed, nil
 }
 
@@ -371,6 +841,7 @@ func getFieldType(field *protogen.Field) string {
 func (g *OpenAPIGenerator) parseEnum(enum *protogen.Enum) (ParsedEnum, error) {
 	parsed := ParsedEnum{
 		Name:        string(enum.Desc.Name()),
+		FullName:    string(enum.Desc.FullName()),
 		Values:      make([]ParsedEnumValue, 0),
 		Annotations: make(map[string]string),
 		Comment:     string(enum.Comments.Leading),
@@ -385,6 +856,18 @@ func (g *OpenAPIGenerator) parseEnum(enum *protogen.Enum) (ParsedEnum, error) {
 		parsed.Values = append(parsed.Values, parsedValue)
 	}
 
+	// Parse OpenAPI EnumSchema annotation
+	schema, ok := proto.GetExtension(enum.Desc.Options(), options.E_EnumSchema).(*options.Schema)
+	if ok && schema != nil {
+		parsed.Schema = schema
+	}
+
+	// Parse v2 Enum annotation, merged into the v3 annotation
+	v2Enum, ok := proto.GetExtension(enum.Desc.Options(), v2options.E_Openapiv2Enum).(*v2options.EnumSchema)
+	if ok && v2Enum != nil {
+		parsed.Schema = mergeV2Annotation(parsed.Schema, convertV2EnumSchemaToV3(v2Enum), "schema of enum "+parsed.FullName)
+	}
+
 	return parsed, nil
 }
 
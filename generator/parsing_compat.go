@@ -2,140 +2,111 @@ package generator
 
 import (
 	"fmt"
+	"log"
+	"maps"
+	"slices"
 	"strings"
 
 	v2options "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
 	"github.com/sapk/protoc-gen-openapiv3/options"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
-// convertV2ToV3 converts OpenAPI v2 annotations to v3 format
+// convertV2ToV3 converts the OpenAPI v2 annotations of the file to v3 format and merges them
+// into its v3 annotations, which win field by field
 func (g *OpenAPIGenerator) convertV2ToV3(parsed *ParsedFile) error {
 	if parsed.V2Swagger == nil {
 		return nil
 	}
+	swagger := parsed.V2Swagger
+	element := fmt.Sprintf("package %s", parsed.Package)
 
 	// Convert info
-	if parsed.V2Swagger.Info != nil {
-		parsed.Info = &options.Info{
-			Title:          parsed.V2Swagger.Info.Title,
-			Description:    parsed.V2Swagger.Info.Description,
-			TermsOfService: parsed.V2Swagger.Info.TermsOfService,
-			Version:        parsed.V2Swagger.Info.Version,
+	if swagger.Info != nil {
+		info := &options.Info{
+			Title:          swagger.Info.Title,
+			Description:    swagger.Info.Description,
+			TermsOfService: swagger.Info.TermsOfService,
+			Version:        swagger.Info.Version,
+			Extensions:     swagger.Info.GetExtensions(),
 		}
 
 		// Convert contact
-		if parsed.V2Swagger.Info.Contact != nil {
-			parsed.Info.Contact = &options.Contact{
-				Name:  parsed.V2Swagger.Info.Contact.Name,
-				Url:   parsed.V2Swagger.Info.Contact.Url,
-				Email: parsed.V2Swagger.Info.Contact.Email,
+		if swagger.Info.Contact != nil {
+			info.Contact = &options.Contact{
+				Name:  swagger.Info.Contact.Name,
+				Url:   swagger.Info.Contact.Url,
+				Email: swagger.Info.Contact.Email,
 			}
 		}
 
 		// Convert license
-		if parsed.V2Swagger.Info.License != nil {
-			parsed.Info.License = &options.License{
-				Name: parsed.V2Swagger.Info.License.Name,
-				Url:  parsed.V2Swagger.Info.License.Url,
+		if swagger.Info.License != nil {
+			info.License = &options.License{
+				Name: swagger.Info.License.Name,
+				Url:  swagger.Info.License.Url,
 			}
 		}
-	}
 
-	// Convert servers
-	if parsed.V2Swagger.Host != "" || parsed.V2Swagger.BasePath != "" || len(parsed.V2Swagger.Schemes) > 0 {
-		server := convertV2ServerToV3(parsed.V2Swagger.Host, parsed.V2Swagger.BasePath, parsed.V2Swagger.Schemes)
-		if server != nil {
-			parsed.Servers = append(parsed.Servers, server)
-		}
+		parsed.Info = mergeV2Annotation(parsed.Info, info, "info of "+element)
 	}
 
-	// Convert security schemes
-	if parsed.V2Swagger.SecurityDefinitions != nil {
-		for name, scheme := range parsed.V2Swagger.SecurityDefinitions.Security {
-			v3Scheme := &options.SecurityScheme{
-				Description: scheme.Description,
-				Name:        name,
-			}
-
-			switch scheme.Type {
-			case v2options.SecurityScheme_TYPE_BASIC:
-				v3Scheme.Type = "http"
-				v3Scheme.Scheme = "basic"
-			case v2options.SecurityScheme_TYPE_API_KEY:
-				v3Scheme.Type = "apiKey"
-				v3Scheme.In = "header"
-				v3Scheme.Name = scheme.Name
-			case v2options.SecurityScheme_TYPE_OAUTH2:
-				v3Scheme.Type = "oauth2"
-				if scheme.Flow == v2options.SecurityScheme_FLOW_ACCESS_CODE {
-					v3Scheme.Flows = &options.OAuth2Flows{
-						AuthorizationCode: &options.OAuth2Flow{
-							AuthorizationUrl: scheme.AuthorizationUrl,
-							TokenUrl:         scheme.TokenUrl,
-							Scopes:           make([]*options.OAuth2Scope, 0),
-						},
-					}
-					for scopeName, scopeDesc := range scheme.Scopes.Scope {
-						v3Scheme.Flows.AuthorizationCode.Scopes = append(
-							v3Scheme.Flows.AuthorizationCode.Scopes,
-							&options.OAuth2Scope{
-								Name:        scopeName,
-								Description: scopeDesc,
-							},
-						)
-					}
-				}
-			}
+	// Convert the extensions of the document
+	parsed.Extensions = mergeV2Extensions(parsed.Extensions, swagger.GetExtensions(), "extensions of "+element)
 
-			parsed.SecuritySchemes = append(parsed.SecuritySchemes, v3Scheme)
-		}
+	// Convert servers
+	if servers := convertV2ServersToV3(swagger.Host, swagger.BasePath, swagger.Schemes, element); len(servers) > 0 {
+		parsed.Servers = mergeV2AnnotationList(parsed.Servers, servers, "servers of "+element)
 	}
 
-	// Convert security requirements
-	if parsed.V2Swagger.Security != nil {
-		for _, req := range parsed.V2Swagger.Security {
-			for name, value := range req.SecurityRequirement {
-				v3Req := &options.SecurityRequirement{
-					Name:   name,
-					Scopes: value.Scope,
-				}
-				parsed.Security = append(parsed.Security, v3Req)
+	// Convert the schemes of operations into servers of the operations
+	for _, service := range parsed.Services {
+		for _, method := range service.Methods {
+			if len(method.V2Operation.GetSchemes()) == 0 {
+				continue
 			}
+			methodElement := fmt.Sprintf("method %s", method.Name)
+			servers := convertV2ServersToV3(swagger.Host, swagger.BasePath, method.V2Operation.GetSchemes(), methodElement)
+			method.Operation.Servers = mergeV2AnnotationList(method.Operation.Servers, servers, "servers of "+methodElement)
 		}
 	}
 
-	// Convert tags
-	if parsed.V2Swagger.Tags != nil {
-		for _, tag := range parsed.V2Swagger.Tags {
-			v3Tag := &options.Tag{
-				Name:        tag.Name,
-				Description: tag.Description,
-			}
+	// Convert security schemes
+	var securitySchemes []*options.SecurityScheme
+	securityDefinitions := swagger.GetSecurityDefinitions().GetSecurity()
+	for _, name := range slices.Sorted(maps.Keys(securityDefinitions)) {
+		securitySchemes = append(securitySchemes, convertV2SecuritySchemeToV3(name, securityDefinitions[name]))
+	}
+	parsed.SecuritySchemes = mergeV2AnnotationsByKey(parsed.SecuritySchemes, securitySchemes, securitySchemeName,
+		"security scheme of "+element)
 
-			if tag.ExternalDocs != nil {
-				v3Tag.ExternalDocs = &options.ExternalDocumentation{
-					Description: tag.ExternalDocs.Description,
-					Url:         tag.ExternalDocs.Url,
-				}
-			}
+	// Convert security requirements, the schemes of a requirement being required together
+	var security []*options.SecurityRequirement
+	for _, req := range swagger.Security {
+		security = append(security, convertV2SecurityRequirementToV3(req))
+	}
+	parsed.Security = mergeV2AnnotationList(parsed.Security, security, "security of "+element)
 
-			parsed.Tags = append(parsed.Tags, v3Tag)
-		}
+	// Convert tags
+	var tags []*options.Tag
+	for _, tag := range swagger.Tags {
+		tags = append(tags, convertV2TagToV3(tag))
 	}
+	parsed.Tags = mergeV2AnnotationsByKey(parsed.Tags, tags, (*options.Tag).GetName, "tag of "+element)
 
 	// Convert external documentation
-	if parsed.V2Swagger.ExternalDocs != nil {
-		parsed.ExternalDocs = &options.ExternalDocumentation{
-			Description: parsed.V2Swagger.ExternalDocs.Description,
-			Url:         parsed.V2Swagger.ExternalDocs.Url,
-		}
+	if swagger.ExternalDocs != nil {
+		parsed.ExternalDocs = mergeV2Annotation(parsed.ExternalDocs, &options.ExternalDocumentation{
+			Description: swagger.ExternalDocs.Description,
+			Url:         swagger.ExternalDocs.Url,
+		}, "external docs of "+element)
 	}
 
-	// Convert responses
-	if parsed.V2Swagger.Responses != nil {
-		// Note: Response conversion should be handled in the response-specific conversion logic
-		// as it requires handling of different response types and content types
-	}
+	// Convert responses, added to every operation like the defaultResponse option
+	parsed.DefaultResponses = mergeV2AnnotationsByKey(parsed.DefaultResponses, convertV2ResponsesToV3(swagger.Responses),
+		(*options.Response).GetCode, "default response of "+element)
 
 	return nil
 }
@@ -151,6 +122,17 @@ func convertV2OperationToV3(v2Op *v2options.Operation) *options.Operation {
 		Description: v2Op.Description,
 		Tags:        v2Op.Tags,
 		Deprecated:  v2Op.Deprecated,
+		OperationId: v2Op.OperationId,
+		Consumes:    v2Op.Consumes,
+		Produces:    v2Op.Produces,
+		Extensions:  v2Op.Extensions,
+	}
+
+	if v2Op.ExternalDocs != nil {
+		v3Op.ExternalDocs = &options.ExternalDocumentation{
+			Description: v2Op.ExternalDocs.Description,
+			Url:         v2Op.ExternalDocs.Url,
+		}
 	}
 
 	// Convert parameters
@@ -192,118 +174,354 @@ func convertV2OperationToV3(v2Op *v2options.Operation) *options.Operation {
 
 	// Convert responses
 	if len(v2Op.Responses) > 0 {
-		v3Op.Responses = make([]*options.Response, 0)
-		for code, resp := range v2Op.Responses {
-			v3Resp := &options.Response{
-				Code:        code,
-				Description: resp.Description,
+		v3Op.Responses = convertV2ResponsesToV3(v2Op.Responses)
+	}
+
+	// Convert security requirements, the schemes of a requirement being required together
+	for _, req := range v2Op.Security {
+		v3Op.Security = append(v3Op.Security, convertV2SecurityRequirementToV3(req))
+	}
+
+	return v3Op
+}
+
+// convertV2ResponsesToV3 converts OpenAPI v2 responses to v3 format, sorted by code.
+// The examples, keyed by MIME type, become the example of the content of that media type.
+func convertV2ResponsesToV3(v2Responses map[string]*v2options.Response) []*options.Response {
+	v3Responses := make([]*options.Response, 0, len(v2Responses))
+	for _, code := range slices.Sorted(maps.Keys(v2Responses)) {
+		resp := v2Responses[code]
+		v3Resp := &options.Response{
+			Code:        code,
+			Description: resp.GetDescription(),
+			Extensions:  resp.GetExtensions(),
+		}
+
+		// Convert response schema if present
+		var schema *options.Schema
+		if resp.GetSchema() != nil {
+			schema = convertV2MessageSchemaToV3(resp.GetSchema())
+			v3Resp.Content = map[string]*options.MediaType{
+				"application/json": {Schema: schema},
 			}
+		}
 
-			// Convert response schema if present
-			if resp.Schema != nil && resp.Schema.JsonSchema != nil {
-				v3Resp.Content = map[string]*options.MediaType{
-					"application/json": {
-						Schema: convertV2SchemaToV3(resp.Schema.JsonSchema),
-					},
-				}
+		// Convert examples, keyed by MIME type
+		for _, mimeType := range slices.Sorted(maps.Keys(resp.GetExamples())) {
+			if v3Resp.Content == nil {
+				v3Resp.Content = make(map[string]*options.MediaType)
+			}
+			if v3Resp.Content[mimeType] == nil {
+				v3Resp.Content[mimeType] = &options.MediaType{Schema: schema}
 			}
+			v3Resp.Content[mimeType].Example = resp.GetExamples()[mimeType]
+		}
 
-			v3Op.Responses = append(v3Op.Responses, v3Resp)
+		// Convert headers
+		for _, name := range slices.Sorted(maps.Keys(resp.GetHeaders())) {
+			header := resp.GetHeaders()[name]
+			if v3Resp.Headers == nil {
+				v3Resp.Headers = make(map[string]*options.Header)
+			}
+			v3Resp.Headers[name] = &options.Header{
+				Description: header.GetDescription(),
+				Schema: &options.Schema{
+					Type:    header.GetType(),
+					Format:  header.GetFormat(),
+					Default: header.GetDefault(),
+					Pattern: header.GetPattern(),
+				},
+			}
 		}
+
+		v3Responses = append(v3Responses, v3Resp)
 	}
+	return v3Responses
+}
 
-	// Convert security requirements
-	if len(v2Op.Security) > 0 {
-		v3Op.Security = make([]*options.SecurityRequirement, len(v2Op.Security))
-		for i, sec := range v2Op.Security {
-			for name, value := range sec.SecurityRequirement {
-				v3Op.Security[i] = &options.SecurityRequirement{
-					Name:   name,
-					Scopes: value.Scope,
-				}
-			}
+// convertV2TagToV3 converts an OpenAPI v2 tag to v3 format
+func convertV2TagToV3(tag *v2options.Tag) *options.Tag {
+	v3Tag := &options.Tag{
+		Name:        tag.GetName(),
+		Description: tag.GetDescription(),
+		Extensions:  tag.GetExtensions(),
+	}
+	if tag.GetExternalDocs() != nil {
+		v3Tag.ExternalDocs = &options.ExternalDocumentation{
+			Description: tag.GetExternalDocs().GetDescription(),
+			Url:         tag.GetExternalDocs().GetUrl(),
 		}
 	}
+	return v3Tag
+}
 
-	return v3Op
+// convertV2SecuritySchemeToV3 converts an OpenAPI v2 security definition to a v3 security scheme.
+// OAuth2 schemes get the v3 flow of their v2 flow, the application flow becoming clientCredentials.
+func convertV2SecuritySchemeToV3(name string, scheme *v2options.SecurityScheme) *options.SecurityScheme {
+	v3Scheme := &options.SecurityScheme{
+		Description: scheme.GetDescription(),
+		Name:        name,
+		Extensions:  scheme.GetExtensions(),
+	}
+
+	switch scheme.GetType() {
+	case v2options.SecurityScheme_TYPE_BASIC:
+		v3Scheme.Type = "http"
+		v3Scheme.Scheme = "basic"
+	case v2options.SecurityScheme_TYPE_API_KEY:
+		v3Scheme.Type = "apiKey"
+		v3Scheme.ParameterName = scheme.GetName()
+		switch scheme.GetIn() {
+		case v2options.SecurityScheme_IN_QUERY:
+			v3Scheme.In = "query"
+		case v2options.SecurityScheme_IN_HEADER:
+			v3Scheme.In = "header"
+		}
+	case v2options.SecurityScheme_TYPE_OAUTH2:
+		v3Scheme.Type = "oauth2"
+		flow := &options.OAuth2Flow{Scopes: make([]*options.OAuth2Scope, 0)}
+		scopes := scheme.GetScopes().GetScope()
+		for _, scopeName := range slices.Sorted(maps.Keys(scopes)) {
+			flow.Scopes = append(flow.Scopes, &options.OAuth2Scope{
+				Name:        scopeName,
+				Description: scopes[scopeName],
+			})
+		}
+
+		switch scheme.GetFlow() {
+		case v2options.SecurityScheme_FLOW_IMPLICIT:
+			flow.AuthorizationUrl = scheme.GetAuthorizationUrl()
+			v3Scheme.Flows = &options.OAuth2Flows{Implicit: flow}
+		case v2options.SecurityScheme_FLOW_PASSWORD:
+			flow.TokenUrl = scheme.GetTokenUrl()
+			v3Scheme.Flows = &options.OAuth2Flows{Password: flow}
+		case v2options.SecurityScheme_FLOW_APPLICATION:
+			flow.TokenUrl = scheme.GetTokenUrl()
+			v3Scheme.Flows = &options.OAuth2Flows{ClientCredentials: flow}
+		case v2options.SecurityScheme_FLOW_ACCESS_CODE:
+			flow.AuthorizationUrl = scheme.GetAuthorizationUrl()
+			flow.TokenUrl = scheme.GetTokenUrl()
+			v3Scheme.Flows = &options.OAuth2Flows{AuthorizationCode: flow}
+		}
+	}
+
+	return v3Scheme
 }
 
-// convertV2SchemaToV3 converts OpenAPI v2 schema to v3 format
+// convertV2SecurityRequirementToV3 converts an OpenAPI v2 security requirement to v3 format,
+// keeping all its schemes in a single requirement. An empty requirement stays empty.
+func convertV2SecurityRequirementToV3(v2Req *v2options.SecurityRequirement) *options.SecurityRequirement {
+	v3Req := &options.SecurityRequirement{}
+	for _, name := range slices.Sorted(maps.Keys(v2Req.GetSecurityRequirement())) {
+		scopes := v2Req.GetSecurityRequirement()[name].GetScope()
+		if v3Req.Name == "" {
+			v3Req.Name = name
+			v3Req.Scopes = scopes
+			continue
+		}
+		v3Req.Schemes = append(v3Req.Schemes, &options.SecuritySchemeScopes{Name: name, Scopes: scopes})
+	}
+	return v3Req
+}
+
+// convertV2MessageSchemaToV3 converts the OpenAPI v2 schema annotation of a message to v3 format
+func convertV2MessageSchemaToV3(v2Schema *v2options.Schema) *options.Schema {
+	v3Schema := convertV2SchemaToV3(v2Schema.GetJsonSchema())
+	if v3Schema == nil {
+		v3Schema = &options.Schema{}
+	}
+
+	if v2Schema.GetDiscriminator() != "" {
+		v3Schema.Discriminator = &options.Discriminator{PropertyName: v2Schema.GetDiscriminator()}
+	}
+	if v2Schema.GetReadOnly() {
+		v3Schema.ReadOnly = pointerTo(true)
+	}
+	if v2Schema.GetExternalDocs() != nil {
+		v3Schema.ExternalDocs = &options.ExternalDocumentation{
+			Description: v2Schema.GetExternalDocs().GetDescription(),
+			Url:         v2Schema.GetExternalDocs().GetUrl(),
+		}
+	}
+	if v2Schema.GetExample() != "" {
+		v3Schema.Example = v2Schema.GetExample()
+	}
+
+	return v3Schema
+}
+
+// convertV2SchemaToV3 converts OpenAPI v2 schema to v3 format.
+// Like protoc-gen-openapiv2, the array field and the field configuration are not part of the schema.
 func convertV2SchemaToV3(v2Schema *v2options.JSONSchema) *options.Schema {
 	if v2Schema == nil {
 		return nil
 	}
 
 	v3Schema := &options.Schema{
-		Description: v2Schema.Description,
-		Title:       v2Schema.Title,
-		Default:     v2Schema.Default,
-		Format:      v2Schema.Format,
-	}
-
-	// Convert type
-	if len(v2Schema.Type) > 0 {
-		switch v2Schema.Type[0] {
-		case v2options.JSONSchema_STRING:
-			v3Schema.Type = "string"
-		case v2options.JSONSchema_NUMBER:
-			v3Schema.Type = "number"
-		case v2options.JSONSchema_INTEGER:
-			v3Schema.Type = "integer"
-		case v2options.JSONSchema_BOOLEAN:
-			v3Schema.Type = "boolean"
-		case v2options.JSONSchema_OBJECT:
-			v3Schema.Type = "object"
-		case v2options.JSONSchema_ARRAY:
-			v3Schema.Type = "array"
+		Description:      v2Schema.GetDescription(),
+		Title:            v2Schema.GetTitle(),
+		Default:          v2Schema.GetDefault(),
+		Format:           v2Schema.GetFormat(),
+		Example:          v2Schema.GetExample(),
+		MultipleOf:       v2Schema.GetMultipleOf(),
+		Maximum:          v2Schema.GetMaximum(),
+		ExclusiveMaximum: v2Schema.GetExclusiveMaximum(),
+		Minimum:          v2Schema.GetMinimum(),
+		ExclusiveMinimum: v2Schema.GetExclusiveMinimum(),
+		MaxLength:        int32(v2Schema.GetMaxLength()),
+		MinLength:        int32(v2Schema.GetMinLength()),
+		Pattern:          v2Schema.GetPattern(),
+		MaxItems:         int32(v2Schema.GetMaxItems()),
+		MinItems:         int32(v2Schema.GetMinItems()),
+		UniqueItems:      v2Schema.GetUniqueItems(),
+		MaxProperties:    int32(v2Schema.GetMaxProperties()),
+		MinProperties:    int32(v2Schema.GetMinProperties()),
+		Required:         v2Schema.GetRequired(),
+		Enum:             v2Schema.GetEnum(),
+		Extensions:       v2Schema.GetExtensions(),
+	}
+	if v2Schema.GetReadOnly() {
+		v3Schema.ReadOnly = pointerTo(true)
+	}
+
+	// Convert type, the null type making the schema nullable
+	for _, schemaType := range v2Schema.GetType() {
+		switch schemaType {
+		case v2options.JSONSchema_UNKNOWN:
+		case v2options.JSONSchema_NULL:
+			v3Schema.Nullable = pointerTo(true)
+		default:
+			if v3Schema.Type == "" {
+				v3Schema.Type = strings.ToLower(schemaType.String())
+			}
 		}
 	}
 
-	// Convert reference if present
-	if v2Schema.Ref != "" {
-		v3Schema.Ref = convertV2RefToV3(v2Schema.Ref)
+	// Convert reference, an array referencing the schema of its items
+	if v2Schema.GetRef() != "" {
+		ref := convertV2RefToV3(v2Schema.GetRef())
+		if v3Schema.Type == "array" {
+			v3Schema.Items = &options.Schema{Ref: ref}
+		} else {
+			v3Schema.Ref = ref
+		}
+	}
+
+	return v3Schema
+}
+
+// convertV2FieldSchemaToV3 converts the OpenAPI v2 schema annotation of a field to v3 format.
+// Like protoc-gen-openapiv2, the value constraints of a repeated field apply to its items.
+func convertV2FieldSchemaToV3(v2Field *v2options.JSONSchema, repeated bool) *options.Schema {
+	v3Schema := convertV2SchemaToV3(v2Field)
+	if !repeated || (v3Schema.GetType() != "" && v3Schema.GetType() != "array") {
+		return v3Schema
 	}
 
-	// Convert required fields
-	if len(v2Schema.Required) > 0 {
-		v3Schema.Required = v2Schema.Required
+	items := &options.Schema{
+		Default:          v3Schema.GetDefault(),
+		Format:           v3Schema.GetFormat(),
+		MultipleOf:       v3Schema.GetMultipleOf(),
+		Maximum:          v3Schema.GetMaximum(),
+		ExclusiveMaximum: v3Schema.GetExclusiveMaximum(),
+		Minimum:          v3Schema.GetMinimum(),
+		ExclusiveMinimum: v3Schema.GetExclusiveMinimum(),
+		MaxLength:        v3Schema.GetMaxLength(),
+		MinLength:        v3Schema.GetMinLength(),
+		Pattern:          v3Schema.GetPattern(),
+		MaxProperties:    v3Schema.GetMaxProperties(),
+		MinProperties:    v3Schema.GetMinProperties(),
+		Enum:             v3Schema.GetEnum(),
+	}
+	if proto.Size(items) == 0 {
+		return v3Schema
 	}
 
-	// Convert enum values
-	if len(v2Schema.Enum) > 0 {
-		v3Schema.Enum = v2Schema.Enum
+	arraySchema := &options.Schema{
+		Type:        v3Schema.GetType(),
+		Description: v3Schema.GetDescription(),
+		Title:       v3Schema.GetTitle(),
+		Example:     v3Schema.GetExample(),
+		MaxItems:    v3Schema.GetMaxItems(),
+		MinItems:    v3Schema.GetMinItems(),
+		UniqueItems: v3Schema.GetUniqueItems(),
+		Required:    v3Schema.GetRequired(),
+		ReadOnly:    v3Schema.ReadOnly,
+		Nullable:    v3Schema.Nullable,
+		Extensions:  v3Schema.GetExtensions(),
+		Items:       items,
+	}
+	if v3Schema.GetItems() != nil {
+		arraySchema.Items = v3Schema.GetItems()
+		proto.Merge(arraySchema.Items, items)
 	}
+	return arraySchema
+}
 
-	// Convert array items
-	if v2Schema.Type != nil && len(v2Schema.Type) > 0 && v2Schema.Type[0] == v2options.JSONSchema_ARRAY {
-		v3Schema.Items = &options.Schema{
-			Type: "string", // Default to string array, adjust if needed
+// convertV2EnumSchemaToV3 converts the OpenAPI v2 schema annotation of an enum to v3 format.
+// Its required flag has no equivalent, non optional fields being already required.
+func convertV2EnumSchemaToV3(v2Enum *v2options.EnumSchema) *options.Schema {
+	v3Schema := &options.Schema{
+		Description: v2Enum.GetDescription(),
+		Default:     v2Enum.GetDefault(),
+		Title:       v2Enum.GetTitle(),
+		Example:     v2Enum.GetExample(),
+		Extensions:  v2Enum.GetExtensions(),
+	}
+	if v2Enum.GetRef() != "" {
+		v3Schema.Ref = convertV2RefToV3(v2Enum.GetRef())
+	}
+	if v2Enum.GetReadOnly() {
+		v3Schema.ReadOnly = pointerTo(true)
+	}
+	if v2Enum.GetExternalDocs() != nil {
+		v3Schema.ExternalDocs = &options.ExternalDocumentation{
+			Description: v2Enum.GetExternalDocs().GetDescription(),
+			Url:         v2Enum.GetExternalDocs().GetUrl(),
 		}
 	}
-
 	return v3Schema
 }
 
 // convertV2RefToV3 converts OpenAPI v2 reference to v3 format
 func convertV2RefToV3(ref string) string {
+	// Like protoc-gen-openapiv2, a reference starting with a dot is the fully qualified name of a proto type,
+	// resolved to its component when rendering
+	if fullName, ok := strings.CutPrefix(ref, "."); ok {
+		return "#/components/schemas/" + fullName
+	}
 	// Convert from #/definitions/ to #/components/schemas/
 	return strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
 }
 
-// convertV2ServerToV3 converts OpenAPI v2 server information to v3 format
-func convertV2ServerToV3(host, basePath string, schemes []v2options.Scheme) *options.Server {
+// convertV2ServersToV3 converts OpenAPI v2 server information to one v3 server per scheme.
+// Without a host the API is served from the host of the document, so the server URL is the
+// relative base path, the schemes being dropped with a warning.
+func convertV2ServersToV3(host, basePath string, schemes []v2options.Scheme, element string) []*options.Server {
 	if host == "" {
-		return nil
+		if len(schemes) > 0 {
+			log.Printf("warning: %s sets schemes without a host, using the relative server URL of its base path", element)
+		}
+		if basePath == "" {
+			if len(schemes) == 0 {
+				return nil
+			}
+			basePath = "/"
+		}
+		return []*options.Server{{Url: basePath}}
 	}
 
-	// Build the server URL
-	url := ""
-	if len(schemes) > 0 {
-		switch schemes[0] {
+	if len(schemes) == 0 {
+		schemes = []v2options.Scheme{v2options.Scheme_HTTPS}
+	}
+
+	servers := make([]*options.Server, 0, len(schemes))
+	for _, scheme := range schemes {
+		// Build the server URL
+		url := ""
+		switch scheme {
 		case v2options.Scheme_HTTP:
 			url = "http://"
-		case v2options.Scheme_HTTPS:
-			url = "https://"
 		case v2options.Scheme_WS:
 			url = "ws://"
 		case v2options.Scheme_WSS:
@@ -311,16 +529,128 @@ func convertV2ServerToV3(host, basePath string, schemes []v2options.Scheme) *opt
 		default:
 			url = "https://"
 		}
-	} else {
-		url = "https://"
+		url += host + basePath
+
+		servers = append(servers, &options.Server{
+			Url:         url,
+			Description: fmt.Sprintf("Server for %s", host),
+		})
+	}
+	return servers
+}
+
+// mergeV2Annotation merges an annotation converted from grpc-gateway into the v3 annotation of the same element.
+// The v3 annotation wins field by field and the v2 annotation fills the fields it leaves unset.
+// Fields set by both annotations to different values are reported with a warning.
+func mergeV2Annotation[T proto.Message](v3, v2 T, element string) T {
+	if !v2.ProtoReflect().IsValid() {
+		return v3
 	}
-	url += host
-	if basePath != "" {
-		url += basePath
+	if !v3.ProtoReflect().IsValid() {
+		return v2
 	}
 
-	return &options.Server{
-		Url:         url,
-		Description: fmt.Sprintf("Server for %s", host),
+	merged := proto.Clone(v3).(T)
+	mergeV2Message(merged.ProtoReflect(), v2.ProtoReflect(), element, "")
+	return merged
+}
+
+// mergeV2Message fills the fields of dst left unset with the fields of src, recursing into messages and maps.
+// Lists are kept whole, the v3 list winning over the v2 one.
+func mergeV2Message(dst, src protoreflect.Message, element, path string) {
+	src.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		fieldPath := string(fd.Name())
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+
+		switch {
+		case !dst.Has(fd):
+			// A member of a oneof must not replace the member set by the v3 annotation
+			if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && dst.WhichOneof(oneof) != nil {
+				warnV2Conflict(element, fieldPath)
+				return true
+			}
+			dst.Set(fd, value)
+		case fd.IsMap():
+			dstMap := dst.Mutable(fd).Map()
+			value.Map().Range(func(key protoreflect.MapKey, entry protoreflect.Value) bool {
+				entryPath := fieldPath + "." + key.String()
+				switch {
+				case !dstMap.Has(key):
+					dstMap.Set(key, entry)
+				case fd.MapValue().Message() != nil && !isStructValue(fd.MapValue().Message()):
+					mergeV2Message(dstMap.Mutable(key).Message(), entry.Message(), element, entryPath)
+				case !dstMap.Get(key).Equal(entry):
+					warnV2Conflict(element, entryPath)
+				}
+				return true
+			})
+		case fd.Message() != nil && !fd.IsList():
+			mergeV2Message(dst.Mutable(fd).Message(), value.Message(), element, fieldPath)
+		case !dst.Get(fd).Equal(value):
+			warnV2Conflict(element, fieldPath)
+		}
+		return true
+	})
+}
+
+// isStructValue reports whether a message is a JSON value, such as the value of a specification extension,
+// which is merged as a whole
+func isStructValue(message protoreflect.MessageDescriptor) bool {
+	return message.FullName() == (&structpb.Value{}).ProtoReflect().Descriptor().FullName()
+}
+
+// mergeV2AnnotationList keeps the v3 annotations of a list when set, falling back to the v2 annotations
+func mergeV2AnnotationList[T proto.Message](v3, v2 []T, element string) []T {
+	if len(v3) == 0 {
+		return v2
+	}
+	if len(v2) > 0 && !slices.EqualFunc(v3, v2, func(a, b T) bool { return proto.Equal(a, b) }) {
+		warnV2Conflict(element, "list")
+	}
+	return v3
+}
+
+// mergeV2AnnotationsByKey merges two lists of annotations matching their elements by key.
+// Elements found in both lists are merged by mergeV2Annotation, the other v2 elements are appended.
+func mergeV2AnnotationsByKey[T proto.Message](v3, v2 []T, key func(T) string, element string) []T {
+	merged := slices.Clone(v3)
+	for _, v2Item := range v2 {
+		i := slices.IndexFunc(merged, func(item T) bool { return key(item) == key(v2Item) })
+		if i < 0 {
+			merged = append(merged, v2Item)
+			continue
+		}
+		merged[i] = mergeV2Annotation(merged[i], v2Item, fmt.Sprintf("%s %q", element, key(v2Item)))
 	}
+	return merged
+}
+
+// mergeV2Extensions merges the specification extensions of a v2 annotation into those of the v3 annotation,
+// the v3 value of an extension set by both winning
+func mergeV2Extensions(v3, v2 map[string]*structpb.Value, element string) map[string]*structpb.Value {
+	if len(v2) == 0 {
+		return v3
+	}
+
+	merged := maps.Clone(v3)
+	if merged == nil {
+		merged = make(map[string]*structpb.Value, len(v2))
+	}
+	for _, key := range slices.Sorted(maps.Keys(v2)) {
+		value, exists := merged[key]
+		switch {
+		case !exists:
+			merged[key] = v2[key]
+		case !proto.Equal(value, v2[key]):
+			warnV2Conflict(element, key)
+		}
+	}
+	return merged
+}
+
+// warnV2Conflict reports a field set to different values by the v2 and v3 annotations of an element
+func warnV2Conflict(element, field string) {
+	log.Printf("warning: %s sets %s in both its v2 and v3 annotations with different values, keeping the v3 value", element, field)
 }
@@ -90,6 +90,9 @@ func TestParseProtoFile(t *testing.T) {
 	assert.Equal(t, "test.package.User", getUser.OutputType)
 	assert.Equal(t, "GET", getUser.HTTPMethod)
 	assert.Equal(t, "/v1/users/{user_id}", getUser.HTTPPath)
+	// The v3 operation annotation is kept when the method has no v2 annotation
+	require.NotNil(t, getUser.Operation)
+	assert.Equal(t, "GetUser retrieves a user by ID (override)", getUser.Operation.GetSummary())
 
 	// Verify ListUsers method
 	listUsers := methods["ListUsers"]
@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"fmt"
+
+	high "github.com/pb33f/libopenapi/datamodel/high/v3"
+
+	"github.com/sapk/protoc-gen-openapiv3/options"
+)
+
+// validateDefaultResponses checks that the default responses of the file and of its services have a code
+func validateDefaultResponses(parsedFile *ParsedFile) error {
+	for _, resp := range parsedFile.DefaultResponses {
+		if resp.GetCode() == "" {
+			return fmt.Errorf("default response of file %s requires a code", parsedFile.Package)
+		}
+	}
+	for _, service := range parsedFile.Services {
+		for _, resp := range service.DefaultResponses {
+			if resp.GetCode() == "" {
+				return fmt.Errorf("default response of service %s requires a code", service.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// addDefaultResponses adds the default responses of the service, then those of the file, to an operation.
+// Codes already documented by the operation, or by the service for file responses, are kept.
+func addDefaultResponses(parsedFile *ParsedFile, service ParsedService, operation *high.Operation, doc *high.Document) {
+	for _, responses := range [][]*options.Response{service.DefaultResponses, parsedFile.DefaultResponses} {
+		for _, resp := range responses {
+			if _, exists := operation.Responses.Codes.Get(resp.GetCode()); exists {
+				continue
+			}
+			operation.Responses.Codes.Set(resp.GetCode(), convertResponse(parsedFile, resp, doc))
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+
+	"github.com/sapk/protoc-gen-openapiv3/options"
+)
+
+// securitySchemeName returns the key of a security scheme in the components, defaulting to its type
+func securitySchemeName(scheme *options.SecurityScheme) string {
+	if scheme.GetName() != "" {
+		return scheme.GetName()
+	}
+	return scheme.GetType()
+}
+
+// securitySchemeParameterName returns the name of the parameter carrying an API key,
+// falling back to the scheme name for annotations written before parameter_name existed
+func securitySchemeParameterName(scheme *options.SecurityScheme) string {
+	if scheme.GetType() != "apiKey" {
+		return ""
+	}
+	if scheme.GetParameterName() != "" {
+		return scheme.GetParameterName()
+	}
+	return scheme.GetName()
+}
+
+// securitySchemeNames returns the names of the security schemes of a file, checking that they are unique
+func securitySchemeNames(parsedFile *ParsedFile) (map[string]bool, error) {
+	schemes := make(map[string]bool)
+	for _, scheme := range parsedFile.SecuritySchemes {
+		name := securitySchemeName(scheme)
+		if schemes[name] {
+			return nil, fmt.Errorf("duplicate security scheme %q: set a distinct name on each scheme", name)
+		}
+		schemes[name] = true
+	}
+	return schemes, nil
+}
+
+// validateSecurityRequirements checks that every security requirement of the file and of its operations
+// references one of the given security schemes
+func validateSecurityRequirements(parsedFile *ParsedFile, schemes map[string]bool) error {
+	checkRequirements := func(requirements []*options.SecurityRequirement, owner string) error {
+		for _, req := range requirements {
+			for _, name := range requirementSchemes(req) {
+				if !schemes[name] {
+					return fmt.Errorf("security requirement of %s references unknown security scheme %q", owner, name)
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := checkRequirements(parsedFile.Security, "file "+parsedFile.Package); err != nil {
+		return err
+	}
+	for _, service := range parsedFile.Services {
+		for _, method := range service.Methods {
+			owner := "method " + service.Name + "." + method.Name
+			if method.Operation.GetNoSecurity() && len(method.Security) > 0 {
+				return fmt.Errorf("%s cannot set both security requirements and no_security", owner)
+			}
+			if err := checkRequirements(method.Security, owner); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateMergedSecurity checks the security requirements of merged files against the security schemes
+// declared by all of them, the schemes being commonly declared by a single file
+func validateMergedSecurity(parsedFiles []*ParsedFile) error {
+	schemes := make(map[string]bool)
+	for _, parsedFile := range parsedFiles {
+		for _, scheme := range parsedFile.SecuritySchemes {
+			schemes[securitySchemeName(scheme)] = true
+		}
+	}
+
+	for _, parsedFile := range parsedFiles {
+		if err := validateSecurityRequirements(parsedFile, schemes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requirementSchemes lists the names of the security schemes a requirement combines
+func requirementSchemes(req *options.SecurityRequirement) []string {
+	var names []string
+	if req.GetName() != "" {
+		names = append(names, req.GetName())
+	}
+	for _, scheme := range req.GetSchemes() {
+		names = append(names, scheme.GetName())
+	}
+	return names
+}
+
+// convertSecurityRequirements converts security requirement annotations,
+// each of them combining its named scheme with its other schemes
+func convertSecurityRequirements(requirements []*options.SecurityRequirement) []*base.SecurityRequirement {
+	converted := make([]*base.SecurityRequirement, len(requirements))
+	for i, req := range requirements {
+		converted[i] = &base.SecurityRequirement{
+			Requirements: orderedmap.New[string, []string](),
+		}
+		if req.GetName() != "" {
+			converted[i].Requirements.Set(req.GetName(), req.GetScopes())
+		}
+		for _, scheme := range req.GetSchemes() {
+			converted[i].Requirements.Set(scheme.GetName(), scheme.GetScopes())
+		}
+		converted[i].ContainsEmptyRequirement = converted[i].Requirements.Len() == 0
+	}
+	return converted
+}
+
+// validateSecurityScheme checks that a security scheme sets the fields its type requires,
+// and only the fields that apply to its type
+func validateSecurityScheme(scheme *options.SecurityScheme, openAPI30 bool) error {
+	name := securitySchemeName(scheme)
+	unexpected := func(field string, set bool) error {
+		if set {
+			return fmt.Errorf("security scheme %q of type %s cannot set %s", name, scheme.GetType(), field)
+		}
+		return nil
+	}
+
+	var errs []error
+	switch scheme.GetType() {
+	case "apiKey":
+		switch scheme.GetIn() {
+		case "header", "query", "cookie":
+		default:
+			return fmt.Errorf("security scheme %q of type apiKey must be in header, query or cookie, not %q", name, scheme.GetIn())
+		}
+		if securitySchemeParameterName(scheme) == "" {
+			return fmt.Errorf("security scheme %q of type apiKey requires a parameter_name", name)
+		}
+		errs = append(errs, unexpected("scheme", scheme.GetScheme() != ""))
+	case "http":
+		if scheme.GetScheme() == "" {
+			return fmt.Errorf("security scheme %q of type http requires a scheme, e.g. basic, bearer or digest", name)
+		}
+		if scheme.GetBearerFormat() != "" && !strings.EqualFold(scheme.GetScheme(), "bearer") {
+			return fmt.Errorf("security scheme %q sets a bearer_format but its http scheme is %q, not bearer", name, scheme.GetScheme())
+		}
+		errs = append(errs, unexpected("in", scheme.GetIn() != ""), unexpected("parameter_name", scheme.GetParameterName() != ""))
+	case "oauth2":
+		if err := validateOAuthFlows(name, scheme.GetFlows()); err != nil {
+			return err
+		}
+		errs = append(errs, unexpected("in", scheme.GetIn() != ""), unexpected("scheme", scheme.GetScheme() != ""))
+	case "openIdConnect":
+		if scheme.GetOpenIdConnectUrl() == "" {
+			return fmt.Errorf("security scheme %q of type openIdConnect requires an open_id_connect_url", name)
+		}
+		errs = append(errs, unexpected("in", scheme.GetIn() != ""), unexpected("scheme", scheme.GetScheme() != ""))
+	case "mutualTLS":
+		if openAPI30 {
+			return fmt.Errorf("security scheme %q of type mutualTLS requires OpenAPI %s", name, OpenAPIVersion31)
+		}
+		errs = append(errs, unexpected("in", scheme.GetIn() != ""), unexpected("scheme", scheme.GetScheme() != ""))
+	default:
+		return fmt.Errorf("security scheme %q has unsupported type %q: must be apiKey, http, oauth2, openIdConnect or mutualTLS", name, scheme.GetType())
+	}
+
+	// Fields of other types
+	if scheme.GetType() != "http" {
+		errs = append(errs, unexpected("bearer_format", scheme.GetBearerFormat() != ""))
+	}
+	if scheme.GetType() != "oauth2" {
+		errs = append(errs, unexpected("flows", scheme.GetFlows() != nil))
+	}
+	if scheme.GetType() != "openIdConnect" {
+		errs = append(errs, unexpected("open_id_connect_url", scheme.GetOpenIdConnectUrl() != ""))
+	}
+	return errors.Join(errs...)
+}
+
+// validateOAuthFlows checks that an oauth2 security scheme has flows with the URLs each flow requires
+func validateOAuthFlows(name string, flows *options.OAuth2Flows) error {
+	if flows.GetImplicit() == nil && flows.GetPassword() == nil &&
+		flows.GetClientCredentials() == nil && flows.GetAuthorizationCode() == nil {
+		return fmt.Errorf("security scheme %q of type oauth2 requires at least one flow", name)
+	}
+
+	required := func(flow, field, value string) error {
+		if value == "" {
+			return fmt.Errorf("%s flow of security scheme %q requires a %s", flow, name, field)
+		}
+		return nil
+	}
+
+	if implicit := flows.GetImplicit(); implicit != nil {
+		if err := required("implicit", "authorization_url", implicit.GetAuthorizationUrl()); err != nil {
+			return err
+		}
+	}
+	if password := flows.GetPassword(); password != nil {
+		if err := required("password", "token_url", password.GetTokenUrl()); err != nil {
+			return err
+		}
+	}
+	if clientCredentials := flows.GetClientCredentials(); clientCredentials != nil {
+		if err := required("clientCredentials", "token_url", clientCredentials.GetTokenUrl()); err != nil {
+			return err
+		}
+	}
+	if authorizationCode := flows.GetAuthorizationCode(); authorizationCode != nil {
+		if err := required("authorizationCode", "authorization_url", authorizationCode.GetAuthorizationUrl()); err != nil {
+			return err
+		}
+		if err := required("authorizationCode", "token_url", authorizationCode.GetTokenUrl()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSecuritySchemes validates the security schemes of the file and adds them to the components
+func addSecuritySchemes(parsedFile *ParsedFile, doc *high.Document) error {
+	for _, scheme := range parsedFile.SecuritySchemes {
+		if err := validateSecurityScheme(scheme, isOpenAPI30(doc)); err != nil {
+			return err
+		}
+		doc.Components.SecuritySchemes.Set(securitySchemeName(scheme), convertSecurityScheme(scheme))
+	}
+	return nil
+}
+
+// convertSecurityScheme converts a security scheme annotation, keeping the fields of its type
+func convertSecurityScheme(scheme *options.SecurityScheme) *high.SecurityScheme {
+	securityScheme := &high.SecurityScheme{
+		Type:        scheme.GetType(),
+		Description: scheme.GetDescription(),
+		Extensions:  convertExtensions(scheme.GetExtensions()),
+	}
+
+	switch scheme.GetType() {
+	case "apiKey":
+		securityScheme.Name = securitySchemeParameterName(scheme)
+		securityScheme.In = scheme.GetIn()
+	case "http":
+		securityScheme.Scheme = scheme.GetScheme()
+		securityScheme.BearerFormat = scheme.GetBearerFormat()
+	case "oauth2":
+		flows := scheme.GetFlows()
+		securityScheme.Flows = &high.OAuthFlows{
+			Implicit:          convertOAuthFlow(flows.GetImplicit()),
+			Password:          convertOAuthFlow(flows.GetPassword()),
+			ClientCredentials: convertOAuthFlow(flows.GetClientCredentials()),
+			AuthorizationCode: convertOAuthFlow(flows.GetAuthorizationCode()),
+		}
+	case "openIdConnect":
+		securityScheme.OpenIdConnectUrl = scheme.GetOpenIdConnectUrl()
+	}
+
+	return securityScheme
+}
+
+// convertOAuthFlow converts an OAuth2 flow annotation and its scopes, returning nil for a missing flow
+func convertOAuthFlow(flow *options.OAuth2Flow) *high.OAuthFlow {
+	if flow == nil {
+		return nil
+	}
+
+	converted := &high.OAuthFlow{
+		AuthorizationUrl: flow.GetAuthorizationUrl(),
+		TokenUrl:         flow.GetTokenUrl(),
+		RefreshUrl:       flow.GetRefreshUrl(),
+		Scopes:           orderedmap.New[string, string](),
+		Extensions:       convertExtensions(flow.GetExtensions()),
+	}
+	for _, scope := range flow.GetScopes() {
+		converted.Scopes.Set(scope.GetName(), scope.GetDescription())
+	}
+	return converted
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"log"
+	"strings"
+
+	high "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sapk/protoc-gen-openapiv3/options"
+)
+
+// streamingExtension marks operations whose streaming cannot be carried over HTTP
+const streamingExtension = "x-grpc-streaming"
+
+// streamingMediaTypesOrDefault returns the media types of a streamed response,
+// defaulting to newline-delimited JSON
+func streamingMediaTypesOrDefault(mediaTypes []string) []string {
+	if len(mediaTypes) == 0 {
+		return []string{"application/x-ndjson"}
+	}
+	return mediaTypes
+}
+
+// streamResultSchema returns a reference to the envelope wrapping each message of a server stream.
+// Like grpc-gateway, every streamed chunk holds either a result or an error.
+func streamResultSchema(parsedFile *ParsedFile, method ParsedMethod, doc *high.Document) *options.Schema {
+	result := convertMessageToSchema(parsedFile, method.OutputType, doc)
+
+	// Keep the qualifier of the result name, e.g. "v1.StreamResultOfUser"
+	resultName := strings.TrimPrefix(result.GetRef(), "#/components/schemas/")
+	qualifier, name := "", resultName
+	if i := strings.LastIndex(resultName, "."); i >= 0 {
+		qualifier, name = resultName[:i+1], resultName[i+1:]
+	}
+	envelopeName := qualifier + "StreamResultOf" + name
+
+	if _, exists := doc.Components.Schemas.Get(envelopeName); !exists {
+		doc.Components.Schemas.Set(envelopeName, convertSchemaToOpenAPI(&options.Schema{
+			Type:        "object",
+			Title:       "Stream result of " + name,
+			Description: "Each message of the stream holds either a result or an error",
+			Properties: map[string]*options.Schema{
+				"result": result,
+				"error":  statusSchema(parsedFile, doc),
+			},
+		}, doc))
+	}
+
+	return &options.Schema{Ref: "#/components/schemas/" + envelopeName}
+}
+
+// markUnsupportedStreaming flags client and bidirectional streaming operations, which a single
+// HTTP request cannot carry, with a warning and the x-grpc-streaming extension
+func markUnsupportedStreaming(method ParsedMethod, operation *high.Operation) {
+	var kind string
+	switch {
+	case method.ClientStreaming && method.ServerStreaming:
+		kind = "bidirectional"
+	case method.ClientStreaming:
+		kind = "client"
+	default:
+		return
+	}
+
+	log.Printf("warning: %s streaming method %s cannot be carried by a single HTTP request, only one request message is documented", kind, method.Name)
+
+	if operation.Extensions == nil {
+		operation.Extensions = orderedmap.New[string, *yaml.Node]()
+	}
+	operation.Extensions.Set(streamingExtension, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: kind})
+}
@@ -18,8 +18,16 @@ var (
 	allowMerge        = flags.Bool("allow_merge", false, "if true, merge generation_opt into a single file")
 	includePkgInTags  = flags.Bool("include_package_in_tags", false, "if true, include the package name in the operation tags")
 	fqnForOpenAPIName = flags.Bool("fqn_for_openapi_name", false, "if true, use the full qualified name for OpenAPI names")
-	outputFile        = flags.String("output", "openapi.yaml", "path to OpenAPI configuration file")
+	namingStrategy    = flags.String("openapi_naming_strategy", "", "naming strategy of schema components (simple, package, fqn or unique)")
+	operationIDTmpl   = flags.String("operation_id_template", generator.DefaultOperationIDTemplate, "template of generated operationIds, using {Method}, {Service} and {package}")
+	operationIDCase   = flags.String("operation_id_case", "", "letter case of generated operationIds (camel or snake)")
+	disableDefErrors  = flags.Bool("disable_default_errors", false, "if true, do not add a default google.rpc.Status error response to operations")
+	defaultErrorType  = flags.String("default_error_type", "", "fully qualified message used by the default error response instead of google.rpc.Status")
+	outputFile        = flags.String("output", "", "path of the merged OpenAPI file when allow_merge is set (defaults to openapi.yaml or openapi.json)")
 	outputFormat      = flags.String("output-format", "yaml", "format of OpenAPI configuration file")
+	jsonSchemaDialect = flags.String("json_schema_dialect", "", "default jsonSchemaDialect URI of the generated OpenAPI document")
+	openAPIVersion    = flags.String("openapi_version", "3.1", "OpenAPI version of the generated document (3.0 or 3.1)")
+	migrate           = flags.Bool("migrate", false, "if true, write the protoc_gen_openapiv3 annotations equivalent to the openapiv2 annotations instead of the OpenAPI specifications")
 )
 
 func main() {
@@ -58,6 +66,14 @@ func main() {
 			FQNForOpenAPIName:    *fqnForOpenAPIName,
 			OutputFile:           *outputFile,
 			OutputFormat:         generator.OutputFormat(*outputFormat),
+			JSONSchemaDialect:    *jsonSchemaDialect,
+			OpenAPIVersion:       generator.OpenAPIVersion(*openAPIVersion),
+			NamingStrategy:       generator.NamingStrategy(*namingStrategy),
+			OperationIDTemplate:  *operationIDTmpl,
+			OperationIDCase:      generator.OperationIDCase(*operationIDCase),
+			DisableDefaultErrors: *disableDefErrors,
+			DefaultErrorType:     strings.TrimPrefix(*defaultErrorType, "."),
+			Migrate:              *migrate,
 		})
 
 		// Process each proto file
@@ -69,6 +85,11 @@ func main() {
 			}
 		}
 
+		// Write the merged spec if enabled
+		if err := generator.Finish(); err != nil {
+			return fmt.Errorf("failed to generate merged OpenAPI spec: %v", err)
+		}
+
 		return nil
 	})
 }